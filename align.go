@@ -0,0 +1,169 @@
+package diffator
+
+// maxAlignEdits limits the number of insertions and deletions alignElements()
+// searches for before giving up and pairing the remaining elements by index.
+const maxAlignEdits = 1000
+
+// maxAlignMisses limits the number of pairs of elements diffElements() finds to
+// differ while aligning them before it gives up and pairs them by index.
+const maxAlignMisses = 1 << 12
+
+// elementOpKind says how an element of want relates to an element of got.
+type elementOpKind int
+
+const (
+	changedElement elementOpKind = iota
+	deletedElement
+	insertedElement
+)
+
+// elementOp is one difference found when aligning two sequences. want is the
+// index in the want sequence, or -1 for an inserted element, and got is the
+// index in the got sequence, or -1 for a deleted element.
+type elementOp struct {
+	kind elementOpKind
+	want int
+	got  int
+}
+
+// alignElements aligns a want sequence of n elements with a got sequence of m
+// elements using Myers' algorithm, where eq reports whether want[i] equals
+// got[j], and returns the differences in the order found. Elements that are
+// neither equal nor aligned are paired as changed elements when a deletion
+// and an insertion occur at the same place, so that their own differences can
+// be shown.
+func alignElements(n, m int, eq func(i, j int) bool) (ops []elementOp) {
+	var prefix, suffix int
+	var moves []alignMove
+	var ok bool
+
+	for prefix < n && prefix < m && eq(prefix, prefix) {
+		prefix++
+	}
+	for suffix < n-prefix && suffix < m-prefix && eq(n-1-suffix, m-1-suffix) {
+		suffix++
+	}
+	moves, ok = myersMoves(n-prefix-suffix, m-prefix-suffix, func(i, j int) bool {
+		return eq(prefix+i, prefix+j)
+	})
+	if !ok {
+		return pairElements(nil, prefix, n-suffix, prefix, m-suffix)
+	}
+	i, j := prefix, prefix
+	start1, start2 := i, j
+	for _, mv := range moves {
+		switch mv {
+		case equalMove:
+			ops = pairElements(ops, start1, i, start2, j)
+			i++
+			j++
+			start1, start2 = i, j
+		case deleteMove:
+			i++
+		case insertMove:
+			j++
+		}
+	}
+	return pairElements(ops, start1, i, start2, j)
+}
+
+// pairElements appends the ops for a run of want elements lo1 to hi1-1 that
+// were replaced by got elements lo2 to hi2-1: elements at the same offset
+// within the run are changed, and the remainder deleted or inserted.
+func pairElements(ops []elementOp, lo1, hi1, lo2, hi2 int) []elementOp {
+	cnt := min(hi1-lo1, hi2-lo2)
+	for k := 0; k < cnt; k++ {
+		ops = append(ops, elementOp{kind: changedElement, want: lo1 + k, got: lo2 + k})
+	}
+	for i := lo1 + cnt; i < hi1; i++ {
+		ops = append(ops, elementOp{kind: deletedElement, want: i, got: -1})
+	}
+	for j := lo2 + cnt; j < hi2; j++ {
+		ops = append(ops, elementOp{kind: insertedElement, want: -1, got: j})
+	}
+	return ops
+}
+
+// alignMove is a step through the edit graph of Myers' algorithm.
+type alignMove byte
+
+const (
+	equalMove alignMove = iota
+	deleteMove
+	insertMove
+)
+
+// myersMoves returns the shortest sequence of moves that turns a sequence of n
+// elements into one of m elements, or false if that takes more than
+// maxAlignEdits insertions and deletions.
+func myersMoves(n, m int, eq func(i, j int) bool) (moves []alignMove, ok bool) {
+	var trace [][]int
+	var x, y int
+
+	maxD := min(n+m, maxAlignEdits)
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	for d := 0; d <= maxD; d++ {
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y = x - k
+			for x < n && y < m && eq(x, y) {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrackMoves(trace, n, m), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrackMoves walks trace, which holds the furthest x reached on each
+// diagonal k after each round d of myersMoves(), back from (n,m) to (0,0).
+func backtrackMoves(trace [][]int, n, m int) []alignMove {
+	var prevK int
+	moves := make([]alignMove, 0, n+m)
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int {
+			return prev[k+d-1]
+		}
+		k := x - y
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			moves = append(moves, equalMove)
+			x--
+			y--
+		}
+		if x == prevX {
+			moves = append(moves, insertMove)
+		} else {
+			moves = append(moves, deleteMove)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		moves = append(moves, equalMove)
+		x--
+		y--
+	}
+	for i, j := 0, len(moves)-1; i < j; i, j = i+1, j-1 {
+		moves[i], moves[j] = moves[j], moves[i]
+	}
+	return moves
+}
@@ -0,0 +1,90 @@
+package diffator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// lcsLength is the O(n·m) dynamic programming length of the longest common
+// subsequence of a and b, as a reference for alignElements.
+func lcsLength(a, b []int) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case a[i-1] == b[j-1]:
+				dp[i][j] = dp[i-1][j-1] + 1
+			case dp[i-1][j] > dp[i][j-1]:
+				dp[i][j] = dp[i-1][j]
+			default:
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
+
+func TestAlignElements(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randInts := func(n, alphabet int) []int {
+		ints := make([]int, n)
+		for i := range ints {
+			ints[i] = rnd.Intn(alphabet)
+		}
+		return ints
+	}
+	for iter := 0; iter < 500; iter++ {
+		a := randInts(rnd.Intn(30), 1+rnd.Intn(6))
+		b := randInts(rnd.Intn(30), 1+rnd.Intn(6))
+		ops := alignElements(len(a), len(b), func(i, j int) bool {
+			return a[i] == b[j]
+		})
+
+		// Every element must be accounted for exactly once, in order, and the
+		// elements not in any op must be an optimal common subsequence.
+		inOp1 := make([]bool, len(a))
+		inOp2 := make([]bool, len(b))
+		last1, last2 := -1, -1
+		for _, op := range ops {
+			if op.want >= 0 {
+				if inOp1[op.want] || op.want <= last1 {
+					t.Fatalf("a=%v b=%v: want index %d out of order in %v", a, b, op.want, ops)
+				}
+				inOp1[op.want] = true
+				last1 = op.want
+			}
+			if op.got >= 0 {
+				if inOp2[op.got] || op.got <= last2 {
+					t.Fatalf("a=%v b=%v: got index %d out of order in %v", a, b, op.got, ops)
+				}
+				inOp2[op.got] = true
+				last2 = op.got
+			}
+		}
+		var common1, common2 []int
+		for i, in := range inOp1 {
+			if !in {
+				common1 = append(common1, a[i])
+			}
+		}
+		for j, in := range inOp2 {
+			if !in {
+				common2 = append(common2, b[j])
+			}
+		}
+		if len(common1) != len(common2) {
+			t.Fatalf("a=%v b=%v: unbalanced common elements in %v", a, b, ops)
+		}
+		for k := range common1 {
+			if common1[k] != common2[k] {
+				t.Fatalf("a=%v b=%v: unequal common elements in %v", a, b, ops)
+			}
+		}
+		if want := lcsLength(a, b); len(common1) != want {
+			t.Fatalf("a=%v b=%v: common length %d, want %d", a, b, len(common1), want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package diffator
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// hexDumpWidth is the number of bytes shown per row of a hex dump.
+	hexDumpWidth = 16
+	// maxHexDumpRows limits the number of differing rows shown in a hex dump.
+	maxHexDumpRows = 32
+)
+
+// isBytesType returns true if rt is a slice or array of bytes, e.g. []byte or
+// [16]byte.
+func isBytesType(rt reflect.Type) bool {
+	switch rt.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rt.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// bytesOf returns the bytes of rv, a slice or array of bytes.
+func bytesOf(rv reflect.Value) (b []byte) {
+	if rv.Kind() == reflect.Slice || rv.CanAddr() {
+		b = rv.Bytes()
+		goto end
+	}
+	b = make([]byte, rv.Len())
+	for i := range b {
+		b[i] = byte(rv.Index(i).Uint())
+	}
+end:
+	return b
+}
+
+// diffBytes compares two slices or arrays of bytes as a whole, returning a leaf
+// Diff if they differ.
+func (o *ObjectComparator) diffBytes(rv1, rv2 *reflect.Value, path Path) (d *Diff) {
+	if !bytes.Equal(bytesOf(*rv1), bytesOf(*rv2)) {
+		d = newDiff(ChangedDiff, path, rv1, rv2)
+	}
+	return d
+}
+
+// isText returns true if b is valid UTF-8 without control characters other
+// than tabs and line breaks, so it can be diffed as a string.
+func isText(b []byte) (text bool) {
+	if !utf8.Valid(b) {
+		goto end
+	}
+	for _, r := range string(b) {
+		switch {
+		case r == '\t', r == '\n', r == '\r':
+		case r < 0x20, r == 0x7f:
+			goto end
+		}
+	}
+	text = true
+end:
+	return text
+}
+
+// renderBytes renders the differences between two slices or arrays of bytes,
+// with StringComparator if both are text, e.g. `[]uint8("Hello <(World/Gopher)>!")`,
+// or else as a hex dump of the rows that differ.
+func (o *ObjectComparator) renderBytes(rv1, rv2 *reflect.Value) (diff string) {
+	b1, b2 := bytesOf(*rv1), bytesOf(*rv2)
+	if isText(b1) && isText(b2) {
+		diff = fmt.Sprintf("%s(%s)", rv1.Type(), o.renderString(string(b1), string(b2)))
+		goto end
+	}
+	if !o.opts.PrettyPrint.Value {
+		diff = fmt.Sprintf("%s{\n%s}", rv1.Type(), hexDump(b1, b2, "", o.opts.style))
+		goto end
+	}
+	o.level++
+	diff = hexDump(b1, b2, o.indent(), o.opts.style)
+	o.level--
+	diff = fmt.Sprintf("%s{\n%s%s}", rv1.Type(), diff, o.indent())
+end:
+	return diff
+}
+
+// hexDump returns an `xxd`-style dump of the rows of b1 and b2 that differ, the
+// row of b1 marked `-` and of b2 marked `+`, followed by a row of `^` under the
+// bytes that differ, e.g.:
+//
+//	-00000000: 0102 0304 0506 0708 090a 0b0c 0d0e 0f10  ................
+//	+00000000: 0102 0304 05ff 0708 090a 0b0c 0d0e 0f10  ................
+//	                      ^^                                 ^
+//
+// Rows that are equal are left out, with `...` shown in their place. Each line
+// is prefixed with indent.
+func hexDump(b1, b2 []byte, indent string, style Styler) string {
+	var rows, skipped int
+
+	sb := strings.Builder{}
+	n := max(len(b1), len(b2))
+	for offset := 0; offset < n; offset += hexDumpWidth {
+		row1 := hexRow(b1, offset)
+		row2 := hexRow(b2, offset)
+		differs := hexRowDiffers(row1, row2)
+		if differs == nil {
+			skipped++
+			continue
+		}
+		if skipped > 0 {
+			sb.WriteString(indent)
+			sb.WriteString("...\n")
+			skipped = 0
+		}
+		if rows == maxHexDumpRows {
+			sb.WriteString(indent)
+			sb.WriteString("... more rows differ\n")
+			break
+		}
+		rows++
+		if row1 != nil {
+			sb.WriteString(indent)
+			sb.WriteString("-" + hexLine(offset, row1, differs, style.Want))
+			sb.WriteByte('\n')
+		}
+		if row2 != nil {
+			sb.WriteString(indent)
+			sb.WriteString("+" + hexLine(offset, row2, differs, style.Got))
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(indent)
+		sb.WriteString(caretLine(differs))
+		sb.WriteByte('\n')
+	}
+	if skipped > 0 {
+		sb.WriteString(indent)
+		sb.WriteString("...\n")
+	}
+	return sb.String()
+}
+
+// hexRow returns the row of b starting at offset, or nil if b ends before it.
+func hexRow(b []byte, offset int) []byte {
+	if offset >= len(b) {
+		return nil
+	}
+	return b[offset:min(offset+hexDumpWidth, len(b))]
+}
+
+// hexRowDiffers returns which bytes of row1 and row2 differ, including those
+// only found in one of them, or nil if none do.
+func hexRowDiffers(row1, row2 []byte) (differs []bool) {
+	var differ bool
+
+	differs = make([]bool, max(len(row1), len(row2)))
+	for k := range differs {
+		differs[k] = k >= len(row1) || k >= len(row2) || row1[k] != row2[k]
+		differ = differ || differs[k]
+	}
+	if !differ {
+		differs = nil
+	}
+	return differs
+}
+
+// hexLine formats row as an `xxd` line at offset, with the bytes that differ
+// styled by style.
+func hexLine(offset int, row []byte, differs []bool, style func(string) string) string {
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%08x:", offset))
+	for k := 0; k < hexDumpWidth; k++ {
+		if k%2 == 0 {
+			sb.WriteByte(' ')
+		}
+		switch {
+		case k >= len(row):
+			sb.WriteString("  ")
+		case differs[k]:
+			sb.WriteString(style(fmt.Sprintf("%02x", row[k])))
+		default:
+			sb.WriteString(fmt.Sprintf("%02x", row[k]))
+		}
+	}
+	sb.WriteString("  ")
+	for k, c := range row {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		if differs[k] {
+			sb.WriteString(style(string(c)))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// caretLine returns a line of `^` under the hex digits and characters of the
+// bytes that differ in the lines formatted by hexLine.
+func caretLine(differs []bool) string {
+	const hexStart = len("-00000000: ")
+	const charStart = hexStart + hexDumpWidth*2 + hexDumpWidth/2 - 1 + 2
+	line := []byte(strings.Repeat(" ", charStart+len(differs)))
+	for k, differ := range differs {
+		if !differ {
+			continue
+		}
+		col := hexStart + k*2 + k/2
+		line[col] = '^'
+		line[col+1] = '^'
+		line[charStart+k] = '^'
+	}
+	return strings.TrimRight(string(line), " ")
+}
@@ -0,0 +1,100 @@
+package diffator_test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Frame struct {
+	Payload []byte
+}
+
+func TestCompareObjectsBytes(t *testing.T) {
+	seq := func(n int, changes map[int]byte) []byte {
+		b := make([]byte, n)
+		for i := range b {
+			b[i] = byte(i)
+		}
+		for i, c := range changes {
+			b[i] = c
+		}
+		return b
+	}
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name:     "Equal",
+			v1:       []byte{1, 2, 3},
+			v2:       []byte{1, 2, 3},
+			wantDiff: "",
+		},
+		{
+			name:     "Text",
+			v1:       Frame{Payload: []byte("Hello World!")},
+			v2:       Frame{Payload: []byte("Hello Gopher!")},
+			wantDiff: `diffator_test.Frame{Payload:[]uint8("Hello <(World/Gopher)>!"),}`,
+		},
+		{
+			name: "Binary skips equal rows",
+			v1:   seq(64, nil),
+			v2:   seq(64, map[int]byte{5: 0xff, 50: 'A'}),
+			wantDiff: "[]uint8{\n" +
+				"-00000000: 0001 0203 0405 0607 0809 0a0b 0c0d 0e0f  ................\n" +
+				"+00000000: 0001 0203 04ff 0607 0809 0a0b 0c0d 0e0f  ................\n" +
+				"                       ^^                                ^\n" +
+				"...\n" +
+				"-00000030: 3031 3233 3435 3637 3839 3a3b 3c3d 3e3f  0123456789:;<=>?\n" +
+				"+00000030: 3031 4133 3435 3637 3839 3a3b 3c3d 3e3f  01A3456789:;<=>?\n" +
+				"                ^^                                    ^\n" +
+				"}",
+		},
+		{
+			name: "Binary of different lengths pretty printed",
+			v1:   Frame{Payload: seq(64, nil)},
+			v2:   Frame{Payload: seq(40, map[int]byte{5: 0xff})},
+			opts: &diffator.ObjectOpts{PrettyPrint: diffator.Bool(true)},
+			wantDiff: "\ndiffator_test.Frame{\n" +
+				"  Payload:[]uint8{\n" +
+				"    -00000000: 0001 0203 0405 0607 0809 0a0b 0c0d 0e0f  ................\n" +
+				"    +00000000: 0001 0203 04ff 0607 0809 0a0b 0c0d 0e0f  ................\n" +
+				"                           ^^                                ^\n" +
+				"    ...\n" +
+				"    -00000020: 2021 2223 2425 2627 2829 2a2b 2c2d 2e2f   !\"#$%&'()*+,-./\n" +
+				"    +00000020: 2021 2223 2425 2627                       !\"#$%&'\n" +
+				"                                   ^^^^ ^^^^ ^^^^ ^^^^          ^^^^^^^^\n" +
+				"    -00000030: 3031 3233 3435 3637 3839 3a3b 3c3d 3e3f  0123456789:;<=>?\n" +
+				"               ^^^^ ^^^^ ^^^^ ^^^^ ^^^^ ^^^^ ^^^^ ^^^^  ^^^^^^^^^^^^^^^^\n" +
+				"  },\n" +
+				"}",
+		},
+		{
+			name: "Array",
+			v1:   [4]byte{1, 2, 3, 4},
+			v2:   [4]byte{1, 2, 0, 4},
+			wantDiff: "[4]uint8{\n" +
+				"-00000000: 0102 0304                                ....\n" +
+				"+00000000: 0102 0004                                ....\n" +
+				"                ^^                                    ^\n" +
+				"}",
+		},
+		{
+			name:     "Index mode",
+			v1:       []byte{1, 2},
+			v2:       []byte{1, 3},
+			opts:     &diffator.ObjectOpts{SliceIndexMode: true},
+			wantDiff: "[]uint8{[1](2!=3),}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
@@ -4,3 +4,10 @@ func CompareObjects(v1, v2 any, opts *ObjectOpts) string {
 	c := NewObjectComparator(v1, v2, opts)
 	return c.Compare()
 }
+
+// CompareObjectsResult compares v1 (want) with v2 (got) and returns the tree of
+// differences found, or nil if there are none.
+func CompareObjectsResult(v1, v2 any, opts *ObjectOpts) *Diff {
+	c := NewObjectComparator(v1, v2, opts)
+	return c.Result()
+}
@@ -40,6 +40,12 @@ func TestCompareObjects(t *testing.T) {
 			wantDiff:   "(100!=99)",
 			wantFailed: true,
 		},
+		{
+			name:       "nil-vs-nil:matching",
+			v1:         nil,
+			v2:         nil,
+			wantFailed: false,
+		},
 		{
 			name:       "struct-vs-struct:matching",
 			v1:         &TestStruct{},
@@ -86,7 +92,7 @@ func TestCompareObjects(t *testing.T) {
 			name:       "slice-vs-slice:failing",
 			v1:         []int{3, 4, 5},
 			v2:         []int{3, 4, 6, 7},
-			wantDiff:   "[]int{[2](5!=6),[3](<missing>!=7),}",
+			wantDiff:   "[]int{[2](5!=6),[3]<inserted:7>,}",
 			wantFailed: true,
 		},
 	}
@@ -98,3 +104,99 @@ func TestCompareObjects(t *testing.T) {
 		})
 	}
 }
+
+type UserStruct struct {
+	Name  string
+	Email string
+	Tags  map[string]int
+	Roles []string
+}
+
+func TestCompareObjectsResult(t *testing.T) {
+	type leaf struct {
+		path string
+		kind diffator.DiffKind
+	}
+	tests := []struct {
+		name       string
+		v1         any
+		v2         any
+		wantLeaves []leaf
+	}{
+		{
+			name: "matching",
+			v1:   &UserStruct{Name: "Alice"},
+			v2:   &UserStruct{Name: "Alice"},
+		},
+		{
+			name: "int100vsint99",
+			v1:   100,
+			v2:   99,
+			wantLeaves: []leaf{
+				{path: "", kind: diffator.ChangedDiff},
+			},
+		},
+		{
+			name: "kind-mismatch",
+			v1:   []any{1},
+			v2:   []any{"1"},
+			wantLeaves: []leaf{
+				{path: "[0]", kind: diffator.TypeMismatchDiff},
+			},
+		},
+		{
+			name: "nested-struct",
+			v1: &UserStruct{
+				Name:  "Alice",
+				Email: "alice@example.com",
+				Tags:  map[string]int{"a": 1, "b": 2},
+				Roles: []string{"admin", "user"},
+			},
+			v2: &UserStruct{
+				Name:  "Alice",
+				Email: "alice@example.org",
+				Tags:  map[string]int{"a": 10, "c": 3},
+				Roles: []string{"admin"},
+			},
+			wantLeaves: []leaf{
+				{path: "Email", kind: diffator.ChangedDiff},
+				{path: `Tags["a"]`, kind: diffator.ChangedDiff},
+				{path: `Tags["b"]`, kind: diffator.MissingExpectedDiff},
+				{path: `Tags["c"]`, kind: diffator.MissingActualDiff},
+				{path: "Roles[1]", kind: diffator.MissingExpectedDiff},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := diffator.CompareObjectsResult(tt.v1, tt.v2, nil)
+			if tt.wantLeaves == nil {
+				assert.Nil(t, d)
+				return
+			}
+			var got []leaf
+			for _, l := range d.Leaves() {
+				got = append(got, leaf{path: l.Path.String(), kind: l.Kind})
+			}
+			assert.Equal(t, tt.wantLeaves, got)
+		})
+	}
+}
+
+func TestCompareObjectsResultValues(t *testing.T) {
+	d := diffator.CompareObjectsResult(
+		&TestStruct{Int: 100, String: "hello"},
+		&TestStruct{Int: 99, String: "hello"},
+		nil,
+	)
+	leaves := d.Leaves()
+	if assert.Len(t, leaves, 1) {
+		assert.Equal(t, "Int", leaves[0].Path.String())
+		assert.Equal(t, int64(100), leaves[0].Want.Int())
+		assert.Equal(t, int64(99), leaves[0].Got.Int())
+		assert.Equal(t, "int", leaves[0].WantType().String())
+		assert.Equal(t, "int", leaves[0].GotType().String())
+	}
+	c := diffator.NewObjectComparator(nil, nil, nil)
+	assert.Equal(t, "*diffator_test.TestStruct{Int:(100!=99),}", c.Render(d))
+}
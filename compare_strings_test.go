@@ -1,6 +1,8 @@
 package diffator_test
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/mikeschinkel/go-diffator"
@@ -176,3 +178,349 @@ func TestCompareStrings(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareStringsLineMode(t *testing.T) {
+	type args struct {
+		s1      string
+		s2      string
+		context *diffator.IntValue
+	}
+	var tests = []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "S1 and S2 are the same",
+			args: args{
+				s1: "one\ntwo\n",
+				s2: "one\ntwo\n",
+			},
+			want: "",
+		},
+		{
+			name: "S1 is empty",
+			args: args{
+				s2: "one\ntwo\n",
+			},
+			want: "+one\n+two",
+		},
+		{
+			name: "One changed line",
+			args: args{
+				s1: "one\ntwo\nthree\n",
+				s2: "one\n2\nthree\n",
+			},
+			want: " one\n-two\n+2\n three",
+		},
+		{
+			name: "Added and removed lines",
+			args: args{
+				s1: "<ul>\n  <li>Foo</li>\n  <li>Bar</li>\n</ul>\n",
+				s2: "<ul>\n  <li>Bar</li>\n  <li>Baz</li>\n</ul>\n",
+			},
+			want: " <ul>\n-  <li>Foo</li>\n   <li>Bar</li>\n+  <li>Baz</li>\n </ul>",
+		},
+		{
+			name: "Context lines are elided",
+			args: args{
+				s1:      "a\nb\nc\nd\ne\nf\ng\nh\n",
+				s2:      "a\nb\nc\nD\ne\nf\ng\nh\n",
+				context: diffator.Int(1),
+			},
+			want: "...\n c\n-d\n+D\n e\n...",
+		},
+		{
+			name: "Separate hunks",
+			args: args{
+				s1:      "a\nb\nc\nd\ne\nf\ng\n",
+				s2:      "A\nb\nc\nd\ne\nf\nG\n",
+				context: diffator.Int(1),
+			},
+			want: "-a\n+A\n b\n...\n f\n-g\n+G",
+		},
+		{
+			name: "All context lines",
+			args: args{
+				s1:      "a\nb\nc\nd\ne\n",
+				s2:      "a\nb\nC\nd\ne\n",
+				context: diffator.Int(-1),
+			},
+			want: " a\n b\n-c\n+C\n d\n e",
+		},
+		{
+			name: "Missing newline at end",
+			args: args{
+				s1: "a\nb\n",
+				s2: "a\nb",
+			},
+			want: " a\n-b\n+b\n\\ No newline at end of file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffator.CompareStrings(tt.args.s1, tt.args.s2, &diffator.StringOpts{
+				LineMode:     diffator.Bool(true),
+				ContextLines: tt.args.context,
+			})
+			if got != tt.want {
+				t.Errorf("\ndiff.CompareStrings(s1,s2):\n\t got: %q\n\twant: %q\n", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompareStringsWordTokenizer(t *testing.T) {
+	type args struct {
+		s1     string
+		s2     string
+		pad    *diffator.IntValue
+		format *diffator.StringValue
+	}
+	var tests = []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "Whole words differ",
+			args: args{
+				s1: "Look, it's Batman!!!",
+				s2: "Look, it's Superman!!!",
+			},
+			want: "Look, it's <(Batman/Superman)>!!!",
+		},
+		{
+			name: "Middle word differs",
+			args: args{
+				s1: "failed to open config file: permission denied",
+				s2: "failed to read config file: permission denied",
+			},
+			want: "failed to <(open/read)> config file: permission denied",
+		},
+		{
+			name: "With pad and format",
+			args: args{
+				s1:     "Lorem ipsum may be used as a placeholder before final copy is available.",
+				s2:     "Lorem ipsum is often used as a placeholder awaiting final copy.",
+				pad:    diffator.Int(12),
+				format: diffator.String("{%s|%s}"),
+			},
+			want: "Lorem ipsum {may be|is often} used as a placeholder {before|awaiting} final copy{ is available|}.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffator.CompareStrings(tt.args.s1, tt.args.s2, &diffator.StringOpts{
+				MatchingPadLen:  tt.args.pad,
+				LeftRightFormat: tt.args.format,
+				Tokenizer:       diffator.WordTokenizer,
+			})
+			if got != tt.want {
+				t.Errorf("\ndiff.CompareStrings(s1,s2):\n\t got: %v\n\twant: %v\n", got, tt.want)
+			}
+		})
+	}
+}
+
+// largeStrings returns a JSON-like document of n entries ending with sep, a
+// copy with every 100th value changed, and the expected comparison of the two.
+func largeStrings(n int, sep string) (s1, s2, want string) {
+	var sb1, sb2, sbw strings.Builder
+	for i := 0; i < n; i++ {
+		entry := fmt.Sprintf(`"key%d": "value %d dolor s%%st amet",%s`, i, i*7, sep)
+		switch {
+		case i%100 == 50:
+			sb1.WriteString(fmt.Sprintf(entry, "i"))
+			sb2.WriteString(fmt.Sprintf(entry, "a"))
+			sbw.WriteString(fmt.Sprintf(entry, "<(i/a)>"))
+		default:
+			entry = fmt.Sprintf(entry, "i")
+			sb1.WriteString(entry)
+			sb2.WriteString(entry)
+			sbw.WriteString(entry)
+		}
+	}
+	return sb1.String(), sb2.String(), sbw.String()
+}
+
+func TestCompareStringsLarge(t *testing.T) {
+	word := strings.Repeat("QUJDRA", 20000)
+	multiLine1, multiLine2, multiLineWant := largeStrings(2000, "\n")
+	singleLine1, singleLine2, singleLineWant := largeStrings(2000, " ")
+	var tests = []struct {
+		name string
+		s1   string
+		s2   string
+		want string
+	}{
+		{
+			name: "Multi-line",
+			s1:   multiLine1,
+			s2:   multiLine2,
+			want: multiLineWant,
+		},
+		{
+			name: "Single line",
+			s1:   singleLine1,
+			s2:   singleLine2,
+			want: singleLineWant,
+		},
+		{
+			name: "Single word",
+			s1:   word,
+			s2:   word[:50000] + "Z" + word[50001:],
+			want: word[:50000] + "<(J/Z)>" + word[50001:],
+		},
+		{
+			name: "Too different to compare exactly",
+			s1:   word,
+			s2:   "Z" + strings.Repeat("zyxw", 40000) + "A",
+			want: "<(" + word[:len(word)-1] + "/Z" + strings.Repeat("zyxw", 40000) + ")>A",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffator.CompareStrings(tt.s1, tt.s2, nil)
+			if got != tt.want {
+				t.Errorf("\ndiff.CompareStrings(s1,s2):\n\t got: %.200v\n\twant: %.200v\n", got, tt.want)
+			}
+		})
+	}
+}
+
+// benchmarkStrings returns a multi-line JSON-like document of about size bytes
+// and a copy with a value changed roughly every 1 KB.
+func benchmarkStrings(size int) (s1, s2 string) {
+	var sb1, sb2 strings.Builder
+	sb1.WriteString("{\n")
+	sb2.WriteString("{\n")
+	for i := 0; sb1.Len() < size; i++ {
+		line := fmt.Sprintf("  \"key%d\": \"value %d lorem ipsum dolor sit amet\",\n", i, i*7)
+		sb1.WriteString(line)
+		if i%16 == 7 {
+			line = fmt.Sprintf("  \"key%d\": \"value %d lorem ipsum dolor sat amet\",\n", i, i*7)
+		}
+		sb2.WriteString(line)
+	}
+	sb1.WriteString("}\n")
+	sb2.WriteString("}\n")
+	return sb1.String(), sb2.String()
+}
+
+func BenchmarkCompareStrings(b *testing.B) {
+	sizes := []struct {
+		name string
+		size int
+	}{
+		{name: "1KB", size: 1 << 10},
+		{name: "100KB", size: 100 << 10},
+		{name: "10MB", size: 10 << 20},
+	}
+	for _, sz := range sizes {
+		s1, s2 := benchmarkStrings(sz.size)
+		b.Run(sz.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(s1) + len(s2)))
+			for i := 0; i < b.N; i++ {
+				diffator.CompareStrings(s1, s2, nil)
+			}
+		})
+	}
+}
+
+// BenchmarkCompareStringsSingleLine benchmarks inputs without newlines, such as
+// minified JSON, which are compared word by word once they are large.
+func BenchmarkCompareStringsSingleLine(b *testing.B) {
+	sizes := []struct {
+		name string
+		size int
+	}{
+		{name: "1KB", size: 1 << 10},
+		{name: "100KB", size: 100 << 10},
+		{name: "10MB", size: 10 << 20},
+	}
+	for _, sz := range sizes {
+		s1, s2 := benchmarkStrings(sz.size)
+		s1 = strings.ReplaceAll(s1, "\n", "")
+		s2 = strings.ReplaceAll(s2, "\n", "")
+		b.Run(sz.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(s1) + len(s2)))
+			for i := 0; i < b.N; i++ {
+				diffator.CompareStrings(s1, s2, nil)
+			}
+		})
+	}
+}
+
+func TestCompareStringsUnified(t *testing.T) {
+	type args struct {
+		s1      string
+		s2      string
+		context *diffator.IntValue
+		from    *diffator.StringValue
+		to      *diffator.StringValue
+	}
+	var tests = []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "S1 and S2 are the same",
+			args: args{
+				s1: "one\ntwo\n",
+				s2: "one\ntwo\n",
+			},
+			want: "",
+		},
+		{
+			name: "S1 is empty",
+			args: args{
+				s2: "new\n",
+			},
+			want: "--- want\n+++ got\n@@ -0,0 +1 @@\n+new\n",
+		},
+		{
+			name: "Lines added at both ends",
+			args: args{
+				s1:   "x\ny\n",
+				s2:   "z\nx\ny\nw\n",
+				from: diffator.String("a/file.txt"),
+				to:   diffator.String("b/file.txt"),
+			},
+			want: "--- a/file.txt\n+++ b/file.txt\n@@ -1,2 +1,4 @@\n+z\n x\n y\n+w\n",
+		},
+		{
+			name: "Separate hunks",
+			args: args{
+				s1:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+				s2:      "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+				context: diffator.Int(2),
+			},
+			want: "--- want\n+++ got\n@@ -1,3 +1,3 @@\n-1\n+one\n 2\n 3\n@@ -8,3 +8,3 @@\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name: "Missing newline at end",
+			args: args{
+				s1: "a\nb\nc",
+				s2: "a\nb\nc\n",
+			},
+			want: "--- want\n+++ got\n@@ -1,3 +1,3 @@\n a\n b\n-c\n\\ No newline at end of file\n+c\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffator.CompareStrings(tt.args.s1, tt.args.s2, &diffator.StringOpts{
+				Unified:      diffator.Bool(true),
+				ContextLines: tt.args.context,
+				FromFile:     tt.args.from,
+				ToFile:       tt.args.to,
+			})
+			if got != tt.want {
+				t.Errorf("\ndiff.CompareStrings(s1,s2):\n\t got: %q\n\twant: %q\n", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package diffator
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// CustomComparer compares values of one type in place of ObjectComparator's
+// own comparison. Create one with Comparer() or Differ() and add it to
+// ObjectOpts.Comparers.
+type CustomComparer struct {
+	rt    reflect.Type
+	equal func(a, b any) bool
+	diff  func(path Path, a, b any) *Diff
+}
+
+// Comparer returns a CustomComparer for values of type T that considers them
+// equal if fn returns true, e.g.:
+//
+//	diffator.Comparer(func(a, b time.Time) bool {
+//		return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+//	})
+func Comparer[T any](fn func(a, b T) bool) CustomComparer {
+	return CustomComparer{
+		rt: reflect.TypeOf((*T)(nil)).Elem(),
+		equal: func(a, b any) bool {
+			return fn(a.(T), b.(T))
+		},
+	}
+}
+
+// Differ returns a CustomComparer for values of type T where fn returns the
+// Diff for a and b found at path, or nil if they are equal. The Diff returned
+// is added to the tree as is, so it may have Children of its own.
+func Differ[T any](fn func(path Path, a, b T) *Diff) CustomComparer {
+	return CustomComparer{
+		rt: reflect.TypeOf((*T)(nil)).Elem(),
+		diff: func(path Path, a, b any) *Diff {
+			return fn(path, a.(T), b.(T))
+		},
+	}
+}
+
+// customDiff compares rv1 and rv2 with a registered CustomComparer or an Equal
+// method of their type, if either applies, in which case handled is true.
+func (o *ObjectComparator) customDiff(rv1, rv2 *reflect.Value, path Path) (d *Diff, handled bool) {
+	var a, b any
+	var ok bool
+	var c CustomComparer
+	var method *reflect.Method
+	var out []reflect.Value
+	var rt reflect.Type
+
+	if !rv1.IsValid() || !rv2.IsValid() {
+		goto end
+	}
+	rt = rv1.Type()
+	if rt != rv2.Type() {
+		goto end
+	}
+	switch rv1.Kind() {
+	case reflect.Interface:
+		// The dynamic values are compared once the interfaces are unwrapped
+		goto end
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+		if rv1.IsNil() || rv2.IsNil() {
+			goto end
+		}
+	}
+	a, ok = interfaceOf(rv1)
+	if !ok {
+		goto end
+	}
+	b, ok = interfaceOf(rv2)
+	if !ok {
+		goto end
+	}
+	c, ok = o.opts.comparers[rt]
+	switch {
+	case ok && c.diff != nil:
+		d = c.diff(path, a, b)
+		handled = true
+	case ok:
+		if !c.equal(a, b) {
+			d = newDiff(ChangedDiff, path, rv1, rv2)
+		}
+		handled = true
+	case !o.opts.IgnoreEqualMethods:
+		method = equalMethod(rt)
+		if method == nil {
+			goto end
+		}
+		out = method.Func.Call([]reflect.Value{reflect.ValueOf(a), reflect.ValueOf(b)})
+		if !out[0].Bool() {
+			d = newDiff(ChangedDiff, path, rv1, rv2)
+		}
+		handled = true
+	}
+end:
+	return d, handled
+}
+
+// equalMethodCache maps a reflect.Type to its *reflect.Method for Equal, or nil
+// if it has none.
+var equalMethodCache sync.Map
+
+// equalMethod returns the `Equal(T) bool` method of type rt, or nil if it has
+// none.
+func equalMethod(rt reflect.Type) (method *reflect.Method) {
+	if m, ok := equalMethodCache.Load(rt); ok {
+		return m.(*reflect.Method)
+	}
+	m, ok := rt.MethodByName("Equal")
+	switch {
+	case !ok:
+	case m.Type.NumIn() != 2 || m.Type.In(1) != rt:
+	case m.Type.NumOut() != 1 || m.Type.Out(0).Kind() != reflect.Bool:
+	default:
+		method = &m
+	}
+	equalMethodCache.Store(rt, method)
+	return method
+}
+
+// interfaceOf returns the value held by rv, including the values of unexported
+// struct fields as long as they are addressable.
+func interfaceOf(rv *reflect.Value) (a any, ok bool) {
+	switch {
+	case rv.CanInterface():
+		a = rv.Interface()
+		ok = true
+	case rv.CanAddr():
+		a = reflect.NewAt(rv.Type(), rv.Addr().UnsafePointer()).Elem().Interface()
+		ok = true
+	}
+	return a, ok
+}
+
+// isOpaqueLeaf returns true if d is a difference between values that would
+// normally be broken down into Children, as happens for values compared by a
+// CustomComparer or an Equal method.
+func isOpaqueLeaf(d *Diff) (opaque bool) {
+	if d.Kind != ChangedDiff || !d.IsLeaf() {
+		goto end
+	}
+	switch d.Want.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		opaque = true
+	case reflect.Pointer, reflect.Interface:
+		opaque = !d.Want.IsNil() && !d.Got.IsNil()
+	}
+end:
+	return opaque
+}
+
+// opaqueString returns the string form of a value in an opaque leaf, using its
+// String method if it has one, or else with any redacted fields masked.
+func opaqueString(rv reflect.Value) (s string) {
+	a, ok := interfaceOf(&rv)
+	if !ok {
+		goto reflector
+	}
+	if stringer, ok := a.(fmt.Stringer); ok {
+		s = stringer.String()
+		goto end
+	}
+reflector:
+	s = redactedString(rv)
+end:
+	return s
+}
@@ -0,0 +1,164 @@
+package diffator_test
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Event struct {
+	Name string
+	At   time.Time
+	Host net.IP
+	seen time.Time
+}
+
+type Version struct {
+	Major, Minor int
+}
+
+type Release struct {
+	Name    string
+	Version Version
+	Notes   string
+}
+
+func TestCompareObjectsComparers(t *testing.T) {
+	utc := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	est := utc.In(time.FixedZone("EST", -5*60*60))
+	later := utc.Add(90 * time.Minute)
+
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name: "Equal method for same instant in other zone",
+			v1:   &Event{Name: "deploy", At: utc, Host: net.ParseIP("10.0.0.1"), seen: utc},
+			v2:   &Event{Name: "deploy", At: est, Host: net.IPv4(10, 0, 0, 1).To4(), seen: est},
+		},
+		{
+			name: "Equal method for unexported field of value",
+			v1:   Event{seen: utc},
+			v2:   Event{seen: est},
+		},
+		{
+			name: "Comparer for unexported field of value",
+			v1:   Event{seen: utc},
+			v2:   Event{seen: utc.Add(30 * time.Minute)},
+			opts: &diffator.ObjectOpts{
+				Comparers: []diffator.CustomComparer{
+					diffator.Comparer(func(a, b time.Time) bool {
+						return a.Truncate(time.Hour).Equal(b.Truncate(time.Hour))
+					}),
+				},
+			},
+		},
+		{
+			name:     "Equal method reports whole value",
+			v1:       &Event{At: utc, seen: utc},
+			v2:       &Event{At: later, seen: later},
+			wantDiff: "*diffator_test.Event{At:(2024-01-01 12:00:00 +0000 UTC!=2024-01-01 13:30:00 +0000 UTC),seen:(2024-01-01 12:00:00 +0000 UTC!=2024-01-01 13:30:00 +0000 UTC),}",
+		},
+		{
+			name: "Equal methods ignored",
+			v1:   &Event{Host: net.ParseIP("10.0.0.1")},
+			v2:   &Event{Host: net.ParseIP("10.0.0.1").To4()},
+			opts: &diffator.ObjectOpts{IgnoreEqualMethods: true},
+			wantDiff: "*diffator_test.Event{Host:net.IP{\n" +
+				"-00000000: 0000 0000 0000 0000 0000 ffff 0a00 0001  ................\n" +
+				"+00000000: 0a00 0001                                ....\n" +
+				"           ^^     ^^ ^^^^ ^^^^ ^^^^ ^^^^ ^^^^ ^^^^  ^  ^^^^^^^^^^^^^\n" +
+				"},}",
+		},
+		{
+			name: "Comparer replaces Equal method",
+			v1:   &Event{At: utc},
+			v2:   &Event{At: utc.Add(30 * time.Minute)},
+			opts: &diffator.ObjectOpts{
+				Comparers: []diffator.CustomComparer{
+					diffator.Comparer(func(a, b time.Time) bool {
+						return a.Truncate(time.Hour).Equal(b.Truncate(time.Hour))
+					}),
+				},
+			},
+		},
+		{
+			name: "Comparer for struct type",
+			v1:   &Release{Name: "v1", Version: Version{1, 2}},
+			v2:   &Release{Name: "v1", Version: Version{2, 0}},
+			opts: &diffator.ObjectOpts{
+				Comparers: []diffator.CustomComparer{
+					diffator.Comparer(func(a, b Version) bool {
+						return a.Major == b.Major
+					}),
+				},
+			},
+			wantDiff: "*diffator_test.Release{Version:(diffator_test.Version{Major:1,Minor:2,}!=diffator_test.Version{Major:2,Minor:0,}),}",
+		},
+		{
+			name: "Differ",
+			v1:   &Release{Name: "v1", Notes: "Fixed a bug"},
+			v2:   &Release{Name: "v1", Notes: "fixed a bug."},
+			opts: &diffator.ObjectOpts{
+				Comparers: []diffator.CustomComparer{
+					diffator.Differ(func(path diffator.Path, a, b string) *diffator.Diff {
+						norm := func(s string) string {
+							return strings.TrimSuffix(strings.ToLower(s), ".")
+						}
+						if norm(a) == norm(b) {
+							return nil
+						}
+						return &diffator.Diff{
+							Kind: diffator.ChangedDiff,
+							Path: path,
+							Want: reflect.ValueOf(a),
+							Got:  reflect.ValueOf(b),
+						}
+					}),
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
+
+func TestCompareObjectsResultDiffer(t *testing.T) {
+	opts := &diffator.ObjectOpts{
+		Comparers: []diffator.CustomComparer{
+			diffator.Differ(func(path diffator.Path, a, b Version) *diffator.Diff {
+				if a.Major == b.Major {
+					return nil
+				}
+				return &diffator.Diff{
+					Kind: diffator.ChangedDiff,
+					Path: path,
+					Want: reflect.ValueOf(a.Major),
+					Got:  reflect.ValueOf(b.Major),
+				}
+			}),
+		},
+	}
+	d := diffator.CompareObjectsResult(
+		[]Release{{Version: Version{1, 0}}, {Version: Version{1, 0}}},
+		[]Release{{Version: Version{1, 5}}, {Version: Version{2, 0}}},
+		opts,
+	)
+	leaves := d.Leaves()
+	if assert.Len(t, leaves, 1) {
+		assert.Equal(t, "[1].Version", leaves[0].Path.String())
+		assert.Equal(t, int64(2), leaves[0].Got.Int())
+	}
+}
@@ -12,3 +12,20 @@ const MinSubstrLen = 3
 //	Right String:   "this shows right content inline"
 //	Compare Output: "this shows (left/right) content inline"
 const LeftRightFormat = "<(%s/%s)>"
+
+// ContextLines is the default number of unchanged lines shown before and after
+// each run of changed lines when `CompareStrings()` is used in line mode.
+const ContextLines = 3
+
+// SideBySideWidth is the default width in columns of the output of
+// `CompareStrings()` and `CompareObjects()` when rendered side by side.
+const SideBySideWidth = 80
+
+// maxExactTokens is the number of tokens above which `CompareStrings()` first
+// compares strings line-by-line before comparing the lines that differ, and
+// above which lines that differ are reported whole rather than compared.
+const maxExactTokens = 1 << 14
+
+// maxLineTokens is the number of tokens above which a line of a large input is
+// matched word by word rather than as a whole.
+const maxLineTokens = 1 << 8
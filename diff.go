@@ -0,0 +1,169 @@
+package diffator
+
+import (
+	"reflect"
+)
+
+// DiffKind classifies a node in a Diff tree.
+type DiffKind int
+
+const (
+	// NoDiff is the zero value; it never appears in a Diff tree because equal
+	// values produce no node at all.
+	NoDiff DiffKind = iota
+	// ChangedDiff means want and got are both present but differ. A ChangedDiff
+	// with Children is a container (struct, slice, map, pointer, etc.) whose
+	// differences are found in its children.
+	ChangedDiff
+	// MissingExpectedDiff means an expected (want) value has no counterpart in
+	// got, e.g. a map key only found in want.
+	MissingExpectedDiff
+	// MissingActualDiff means an actual (got) value has no counterpart in want,
+	// e.g. a map key only found in got.
+	MissingActualDiff
+	// TypeMismatchDiff means want and got are of different kinds.
+	TypeMismatchDiff
+	// InvalidDiff means only one of want and got is a valid value.
+	InvalidDiff
+)
+
+func (k DiffKind) String() (s string) {
+	switch k {
+	case NoDiff:
+		s = "none"
+	case ChangedDiff:
+		s = "changed"
+	case MissingExpectedDiff:
+		s = "missing-expected"
+	case MissingActualDiff:
+		s = "missing-actual"
+	case TypeMismatchDiff:
+		s = "type-mismatch"
+	case InvalidDiff:
+		s = "invalid"
+	default:
+		s = "unknown"
+	}
+	return s
+}
+
+// Diff is a node in the tree of differences produced by comparing two objects.
+// A nil *Diff means the values compared equal.
+//
+// Want and Got hold the values found at Path in the want and got objects,
+// respectively. For MissingExpectedDiff Got is the zero reflect.Value, and for
+// MissingActualDiff Want is.
+//
+// Redacted is true for a field tagged `diff:"redact"`, whose values are masked
+// when rendered and whose differences are not broken down into Children.
+type Diff struct {
+	Kind     DiffKind
+	Path     Path
+	Want     reflect.Value
+	Got      reflect.Value
+	Children []*Diff
+	Redacted bool
+
+	// gotIndex is the index in got of a changed element of a slice or array,
+	// whose Path holds its index in want.
+	gotIndex int
+	// opts are the options the Diff was computed with, which Patch() uses to
+	// check that its target matches.
+	opts *ObjectOpts
+}
+
+func newDiff(kind DiffKind, path Path, want, got *reflect.Value) *Diff {
+	d := &Diff{
+		Kind: kind,
+		Path: path,
+	}
+	if want != nil {
+		d.Want = *want
+	}
+	if got != nil {
+		d.Got = *got
+	}
+	return d
+}
+
+// newParentDiff returns a ChangedDiff containing children, ignoring any nil
+// children, or nil if there are no children left.
+func newParentDiff(path Path, want, got *reflect.Value, children ...*Diff) (d *Diff) {
+	for _, child := range children {
+		if child == nil {
+			continue
+		}
+		if d == nil {
+			d = newDiff(ChangedDiff, path, want, got)
+		}
+		d.Children = append(d.Children, child)
+	}
+	return d
+}
+
+// setOpts records opts, the options d was computed with, on d and its
+// descendants.
+func (d *Diff) setOpts(opts *ObjectOpts) {
+	if d == nil {
+		return
+	}
+	d.opts = opts
+	for _, child := range d.Children {
+		child.setOpts(opts)
+	}
+}
+
+// setGotIndex sets the index in got of d, a changed element, if d is not nil.
+func (d *Diff) setGotIndex(index int) {
+	if d != nil {
+		d.gotIndex = index
+	}
+}
+
+// WantType returns the type of the want value, or nil if there is none.
+func (d *Diff) WantType() (rt reflect.Type) {
+	if d.Want.IsValid() {
+		rt = d.Want.Type()
+	}
+	return rt
+}
+
+// GotType returns the type of the got value, or nil if there is none.
+func (d *Diff) GotType() (rt reflect.Type) {
+	if d.Got.IsValid() {
+		rt = d.Got.Type()
+	}
+	return rt
+}
+
+// IsLeaf returns true if the node describes a difference directly rather than
+// aggregating the differences of its children.
+func (d *Diff) IsLeaf() bool {
+	return len(d.Children) == 0
+}
+
+// Leaves returns the leaf nodes of the tree in depth-first order, i.e. every
+// individual difference found.
+func (d *Diff) Leaves() (leaves []*Diff) {
+	d.Walk(func(node *Diff) bool {
+		if node.IsLeaf() {
+			leaves = append(leaves, node)
+		}
+		return true
+	})
+	return leaves
+}
+
+// Walk calls fn for each node in the tree in depth-first order. If fn returns
+// false the children of that node are skipped.
+func (d *Diff) Walk(fn func(*Diff) bool) {
+	if d == nil {
+		return
+	}
+	if !fn(d) {
+		return
+	}
+	for _, child := range d.Children {
+		child.Walk(fn)
+	}
+}
@@ -0,0 +1,81 @@
+package diffator_test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Message struct {
+	Code string
+	Body string
+}
+
+func TestCompareObjectsDiffStrings(t *testing.T) {
+	v1 := Message{Code: "E1", Body: "Hello World!"}
+	v2 := Message{Code: "E2", Body: "Hello Gopher!"}
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name:     "Whole by default",
+			v1:       v1,
+			v2:       v2,
+			wantDiff: `diffator_test.Message{Code:(E1!=E2),Body:(Hello World!!=Hello Gopher!),}`,
+		},
+		{
+			name:     "Diffed",
+			v1:       v1,
+			v2:       v2,
+			opts:     &diffator.ObjectOpts{DiffStrings: diffator.Bool(true)},
+			wantDiff: `diffator_test.Message{Code:"E<(1/2)>",Body:"Hello <(World/Gopher)>!",}`,
+		},
+		{
+			name: "Shorter than minimum length",
+			v1:   v1,
+			v2:   v2,
+			opts: &diffator.ObjectOpts{
+				DiffStrings:       diffator.Bool(true),
+				DiffStringsMinLen: diffator.Int(10),
+			},
+			wantDiff: `diffator_test.Message{Code:(E1!=E2),Body:"Hello <(World/Gopher)>!",}`,
+		},
+		{
+			name: "String options",
+			v1:   Message{Body: "Hello World!"},
+			v2:   Message{Body: "Hello Gopher!"},
+			opts: &diffator.ObjectOpts{
+				DiffStrings: diffator.Bool(true),
+				StringOpts:  &diffator.StringOpts{LeftRightFormat: diffator.String("[-%s+%s]")},
+			},
+			wantDiff: `diffator_test.Message{Body:"Hello [-World+Gopher]!",}`,
+		},
+		{
+			name: "Styled",
+			v1:   Message{Body: "Hello World!"},
+			v2:   Message{Body: "Hello Gopher!"},
+			opts: &diffator.ObjectOpts{
+				DiffStrings: diffator.Bool(true),
+				Color:       diffator.ColorAlways,
+			},
+			wantDiff: "diffator_test.Message{Body:\"\x1b[2mHello \x1b[0m<(\x1b[31mWorld\x1b[0m/\x1b[32mGopher\x1b[0m)>\x1b[2m!\x1b[0m\",}",
+		},
+		{
+			name:     "Map values",
+			v1:       map[string]string{"greeting": "Hello World!"},
+			v2:       map[string]string{"greeting": "Hello Gopher!"},
+			opts:     &diffator.ObjectOpts{DiffStrings: diffator.Bool(true)},
+			wantDiff: `map[string]string{greeting:"Hello <(World/Gopher)>!",}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+// Package dtest provides test assertions that report failures using diffator,
+// so a failing test shows exactly how the value it got differs from the value
+// it wanted.
+//
+// Every assertion accepts optional trailing arguments. Any *diffator.ObjectOpts
+// or *diffator.StringOpts among them configures the comparison, and the rest
+// form a custom message: a format string followed by its arguments, a lone
+// string used as is, or values to print as with fmt.Sprint.
+package dtest
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+)
+
+// Equal reports a test failure if want and got differ when compared with
+// diffator.CompareObjects, and returns true if they are equal.
+func Equal(t testing.TB, want, got any, args ...any) bool {
+	t.Helper()
+	a := parseArgs(args)
+	diff := diffator.CompareObjects(want, got, a.objectOpts)
+	return check(t, diff, a, callSite())
+}
+
+// EqualStrings reports a test failure if want and got differ when compared with
+// diffator.CompareStrings, and returns true if they are equal.
+func EqualStrings(t testing.TB, want, got string, args ...any) bool {
+	t.Helper()
+	a := parseArgs(args)
+	return check(t, compareStrings(want, got, a.stringOpts), a, callSite())
+}
+
+// RequireEqual is like Equal but stops the test with t.FailNow if want and got
+// differ.
+func RequireEqual(t testing.TB, want, got any, args ...any) {
+	t.Helper()
+	a := parseArgs(args)
+	diff := diffator.CompareObjects(want, got, a.objectOpts)
+	if !check(t, diff, a, callSite()) {
+		t.FailNow()
+	}
+}
+
+// RequireEqualStrings is like EqualStrings but stops the test with t.FailNow if
+// want and got differ.
+func RequireEqualStrings(t testing.TB, want, got string, args ...any) {
+	t.Helper()
+	a := parseArgs(args)
+	if !check(t, compareStrings(want, got, a.stringOpts), a, callSite()) {
+		t.FailNow()
+	}
+}
+
+// compareStrings returns the difference between want and got, or "" if they are
+// equal. CompareStrings itself returns the common string when there is no
+// difference in its default inline format.
+func compareStrings(want, got string, opts *diffator.StringOpts) (diff string) {
+	if want == got {
+		goto end
+	}
+	diff = diffator.CompareStrings(want, got, opts)
+end:
+	return diff
+}
+
+// check reports diff as a test failure, if not empty, and returns true if it is
+// empty.
+func check(t testing.TB, diff string, a assertArgs, site string) (ok bool) {
+	t.Helper()
+	if diff == "" {
+		ok = true
+		goto end
+	}
+	t.Errorf("%s", failureMessage(diff, a.message(), site))
+end:
+	return ok
+}
+
+// failureMessage formats a failure for diff with an optional custom message and
+// the call site of the assertion.
+func failureMessage(diff, msg, site string) string {
+	sb := strings.Builder{}
+	sb.WriteString("Not equal (want != got)")
+	if site != "" {
+		sb.WriteString(" at ")
+		sb.WriteString(site)
+	}
+	if msg != "" {
+		sb.WriteString(": ")
+		sb.WriteString(msg)
+	}
+	sb.WriteString("\n")
+	sb.WriteString(strings.TrimPrefix(diff, "\n"))
+	return sb.String()
+}
+
+// callSite returns the file:line of the code that called the exported assertion
+// calling callSite.
+func callSite() (site string) {
+	_, file, line, ok := runtime.Caller(2)
+	if ok {
+		site = fmt.Sprintf("%s:%d", filepath.Base(file), line)
+	}
+	return site
+}
+
+type assertArgs struct {
+	objectOpts *diffator.ObjectOpts
+	stringOpts *diffator.StringOpts
+	msgAndArgs []any
+}
+
+func parseArgs(args []any) (a assertArgs) {
+	for _, arg := range args {
+		switch t := arg.(type) {
+		case *diffator.ObjectOpts:
+			a.objectOpts = t
+		case *diffator.StringOpts:
+			a.stringOpts = t
+		default:
+			a.msgAndArgs = append(a.msgAndArgs, arg)
+		}
+	}
+	return a
+}
+
+func (a assertArgs) message() (msg string) {
+	var format string
+	var ok bool
+
+	if len(a.msgAndArgs) == 0 {
+		goto end
+	}
+	format, ok = a.msgAndArgs[0].(string)
+	switch {
+	case ok && len(a.msgAndArgs) == 1:
+		// A lone message is used as is, so a '%' within it is not a verb
+		msg = format
+	case ok:
+		msg = fmt.Sprintf(format, a.msgAndArgs[1:]...)
+	default:
+		msg = fmt.Sprint(a.msgAndArgs...)
+	}
+end:
+	return msg
+}
@@ -0,0 +1,128 @@
+package dtest_test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/mikeschinkel/go-diffator/dtest"
+	"github.com/stretchr/testify/assert"
+)
+
+type Person struct {
+	Name string
+	Age  int
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		name       string
+		want       any
+		got        any
+		args       []any
+		wantOK     bool
+		wantErrors []string
+	}{
+		{
+			name:   "Equal",
+			want:   &Person{Name: "Alice", Age: 30},
+			got:    &Person{Name: "Alice", Age: 30},
+			wantOK: true,
+		},
+		{
+			name:   "Nil",
+			want:   nil,
+			got:    error(nil),
+			wantOK: true,
+		},
+		{
+			name:   "Not equal",
+			want:   &Person{Name: "Alice", Age: 30},
+			got:    &Person{Name: "Alice", Age: 31},
+			wantOK: false,
+			wantErrors: []string{
+				"Not equal (want != got) at %s\n*dtest_test.Person{Age:(30!=31),}",
+			},
+		},
+		{
+			name:   "Custom message",
+			want:   1,
+			got:    2,
+			args:   []any{"user %d", 42},
+			wantOK: false,
+			wantErrors: []string{
+				"Not equal (want != got) at %s: user 42\n(1!=2)",
+			},
+		},
+		{
+			name:   "Message without args",
+			want:   1,
+			got:    2,
+			args:   []any{"100% done"},
+			wantOK: false,
+			wantErrors: []string{
+				"Not equal (want != got) at %s: 100% done\n(1!=2)",
+			},
+		},
+		{
+			name:   "Options and non-format message",
+			want:   &Person{Name: "Alice"},
+			got:    &Person{Name: "Bob"},
+			args:   []any{&diffator.ObjectOpts{PrettyPrint: diffator.Bool(true)}, 42},
+			wantOK: false,
+			wantErrors: []string{
+				"Not equal (want != got) at %s: 42\n*dtest_test.Person{\n  Name:(Alice!=Bob),\n}",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tb := &fakeTB{}
+			site, ok := callSite(), dtest.Equal(tb, tt.want, tt.got, tt.args...)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, atCallSite(tt.wantErrors, site), tb.errors)
+			assert.Greater(t, tb.helpers, 0, "t.Helper() not called")
+			assert.False(t, tb.failedNow)
+		})
+	}
+}
+
+func TestEqualStrings(t *testing.T) {
+	tb := &fakeTB{}
+	assert.True(t, dtest.EqualStrings(tb, "Hello World", "Hello World"))
+	assert.Empty(t, tb.errors)
+
+	opts := &diffator.StringOpts{Tokenizer: diffator.WordTokenizer}
+	site, ok := callSite(), dtest.EqualStrings(tb, "Look, it's Batman!!!", "Look, it's Superman!!!", opts)
+	assert.False(t, ok)
+	assert.Equal(t, []string{
+		"Not equal (want != got) at " + site + "\nLook, it's <(Batman/Superman)>!!!",
+	}, tb.errors)
+	assert.False(t, tb.failedNow)
+}
+
+func TestRequireEqual(t *testing.T) {
+	tb := &fakeTB{}
+	dtest.RequireEqual(tb, []int{1, 2}, []int{1, 2})
+	assert.False(t, tb.failedNow)
+	assert.Empty(t, tb.errors)
+
+	site := nextLine()
+	dtest.RequireEqual(tb, []int{1, 2}, []int{1, 3})
+	assert.True(t, tb.failedNow)
+	assert.Equal(t, []string{
+		"Not equal (want != got) at " + site + "\n[]int{[1](2!=3),}",
+	}, tb.errors)
+}
+
+func TestRequireEqualStrings(t *testing.T) {
+	tb := &fakeTB{}
+	dtest.RequireEqualStrings(tb, "ABC", "ABC")
+	assert.False(t, tb.failedNow)
+
+	site := nextLine()
+	dtest.RequireEqualStrings(tb, "ABC", "XYZ")
+	assert.True(t, tb.failedNow)
+	assert.Equal(t, []string{
+		"Not equal (want != got) at " + site + "\n<(ABC/XYZ)>",
+	}, tb.errors)
+}
@@ -0,0 +1,266 @@
+package dtest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+)
+
+const (
+	// GoldenDir is the directory, relative to the package being tested, where
+	// golden files are stored.
+	GoldenDir = "testdata"
+	// GoldenExt is the file extension of golden files.
+	GoldenExt = ".golden"
+	// UpdateEnvVar is the environment variable that, when set to a true value
+	// such as `1`, updates golden files.
+	UpdateEnvVar = "DIFFATOR_UPDATE_GOLDEN"
+	// UpdateFlag is the name of the flag that also updates golden files when
+	// set to true, if the test binary defines it. dtest does not define it
+	// itself, so that it does not conflict with tests that already do.
+	UpdateFlag = "update"
+)
+
+// goldenUsed holds the absolute paths of the golden files used by Golden() so
+// far, for ObsoleteGolden().
+var goldenUsed = struct {
+	sync.Mutex
+	paths map[string]struct{}
+}{
+	paths: make(map[string]struct{}),
+}
+
+// Golden compares got to the contents of the golden file for name, stored as
+// GoldenDir/<name>GoldenExt, and reports a test failure with the difference if
+// they differ. If name is empty t.Name() is used. It returns true if they are
+// equal.
+//
+// Strings and []byte are stored as is, and any other value is stored as
+// serialized by diffator.Reflector. Line endings are normalized to `\n` before
+// comparing, and multi-line values are shown as a unified diff unless a
+// *diffator.StringOpts is passed in args.
+//
+// When tests are run with UpdateEnvVar set, or with `-update` if the test
+// binary defines it, the golden file is written with got instead, creating it
+// if needed.
+func Golden(t testing.TB, name string, got any, args ...any) (ok bool) {
+	var data []byte
+	var err error
+	var want, content string
+
+	t.Helper()
+	a := parseArgs(args)
+	site := callSite()
+	if name == "" {
+		name = t.Name()
+	}
+	path := GoldenPath(name)
+	markGoldenUsed(path)
+	content = goldenContent(got)
+
+	if Updating() {
+		err = writeGolden(path, content)
+		if err != nil {
+			t.Errorf("Unable to update golden file %s: %v", path, err)
+			goto end
+		}
+		ok = true
+		goto end
+	}
+	data, err = os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Golden file %s does not exist; run tests with %s=1 to create it",
+			path, UpdateEnvVar,
+		)
+		goto end
+	}
+	if err != nil {
+		t.Errorf("Unable to read golden file %s: %v", path, err)
+		goto end
+	}
+	want = normalizeEOL(string(data))
+	content = normalizeEOL(content)
+	ok = check(t, compareStrings(want, content, goldenOpts(a.stringOpts, path, want, content)), a, site)
+end:
+	return ok
+}
+
+// GoldenPath returns the path of the golden file for name. Slashes in name,
+// such as those in the names of subtests, become subdirectories.
+func GoldenPath(name string) string {
+	return filepath.Join(GoldenDir, filepath.FromSlash(name)+GoldenExt)
+}
+
+// Updating returns true if golden files are being updated rather than compared,
+// i.e. if UpdateEnvVar or the UpdateFlag flag, if defined, is set to true.
+func Updating() (updating bool) {
+	f := flag.Lookup(UpdateFlag)
+	if f != nil {
+		updating, _ = strconv.ParseBool(f.Value.String())
+	}
+	if !updating {
+		updating, _ = strconv.ParseBool(os.Getenv(UpdateEnvVar))
+	}
+	return updating
+}
+
+// ObsoleteGolden returns the golden files in GoldenDir that have not been used
+// by Golden() in this test run, sorted by path.
+func ObsoleteGolden() (obsolete []string, err error) {
+	goldenUsed.Lock()
+	defer goldenUsed.Unlock()
+	err = filepath.WalkDir(GoldenDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || filepath.Ext(path) != GoldenExt {
+			return nil
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if _, used := goldenUsed.paths[abs]; !used {
+			obsolete = append(obsolete, path)
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	sort.Strings(obsolete)
+	return obsolete, err
+}
+
+// RunGolden runs the tests with m.Run() and then checks for obsolete golden
+// files, returning the exit code for os.Exit(). Call it from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//		os.Exit(dtest.RunGolden(m))
+//	}
+//
+// Obsolete golden files fail the run, or are removed when updating. The check
+// is skipped when tests fail or only some tests are run, e.g. with `-run`.
+func RunGolden(m *testing.M) (code int) {
+	var obsolete []string
+	var err error
+
+	code = m.Run()
+	if code != 0 {
+		goto end
+	}
+	if partialRun() {
+		goto end
+	}
+	obsolete, err = ObsoleteGolden()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to check for obsolete golden files: %v\n", err)
+		code = 1
+		goto end
+	}
+	if len(obsolete) == 0 {
+		goto end
+	}
+	if Updating() {
+		for _, path := range obsolete {
+			err = os.Remove(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Unable to remove obsolete golden file %s: %v\n", path, err)
+				code = 1
+				continue
+			}
+			fmt.Fprintf(os.Stderr, "Removed obsolete golden file %s\n", path)
+		}
+		goto end
+	}
+	fmt.Fprintf(os.Stderr, "Obsolete golden files; run tests with %s=1 to remove them:\n", UpdateEnvVar)
+	for _, path := range obsolete {
+		fmt.Fprintf(os.Stderr, "\t%s\n", path)
+	}
+	code = 1
+end:
+	return code
+}
+
+// partialRun returns true if tests were selected with -run or -skip, in which
+// case unused golden files may still be used by tests that did not run.
+func partialRun() (partial bool) {
+	for _, name := range []string{"test.run", "test.skip"} {
+		f := flag.Lookup(name)
+		if f != nil && f.Value.String() != "" {
+			partial = true
+			break
+		}
+	}
+	return partial
+}
+
+func markGoldenUsed(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	goldenUsed.Lock()
+	goldenUsed.paths[abs] = struct{}{}
+	goldenUsed.Unlock()
+}
+
+// goldenContent returns got as stored in a golden file.
+func goldenContent(got any) (s string) {
+	switch t := got.(type) {
+	case string:
+		s = t
+	case []byte:
+		s = string(t)
+	default:
+		rv := reflect.ValueOf(got)
+		s = diffator.NewReflectorFromValue(&rv).String() + "\n"
+	}
+	return s
+}
+
+// writeGolden writes content to path unless it already has that content.
+func writeGolden(path, content string) (err error) {
+	data, err := os.ReadFile(path)
+	if err == nil && string(data) == content {
+		goto end
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		goto end
+	}
+	err = os.WriteFile(path, []byte(content), 0o644)
+end:
+	return err
+}
+
+// goldenOpts returns opts, or if nil the options for comparing want and got: a
+// unified diff for multi-line values, otherwise the CompareStrings defaults.
+func goldenOpts(opts *diffator.StringOpts, path, want, got string) *diffator.StringOpts {
+	if opts != nil {
+		return opts
+	}
+	if strings.Count(want, "\n") <= 1 && strings.Count(got, "\n") <= 1 {
+		return nil
+	}
+	return &diffator.StringOpts{
+		Unified:  diffator.Bool(true),
+		FromFile: diffator.String(filepath.ToSlash(path)),
+		ToFile:   diffator.String("got"),
+	}
+}
+
+func normalizeEOL(s string) string {
+	return strings.ReplaceAll(s, "\r\n", "\n")
+}
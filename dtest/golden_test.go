@@ -0,0 +1,145 @@
+package dtest_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator/dtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update is defined as tests that already have an -update flag do, which dtest
+// must not conflict with, and which Updating() honors.
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name       string
+		golden     string
+		got        any
+		wantOK     bool
+		wantErrors []string
+	}{
+		{
+			name:   "Matching string",
+			golden: "package main\n\nfunc main() {}\n",
+			got:    "package main\n\nfunc main() {}\n",
+			wantOK: true,
+		},
+		{
+			name:   "CRLF line endings",
+			golden: "package main\r\n\r\nfunc main() {}\r\n",
+			got:    []byte("package main\n\nfunc main() {}\n"),
+			wantOK: true,
+		},
+		{
+			name:   "Changed line",
+			golden: "package main\n\nfunc main() {}\n",
+			got:    "package foo\n\nfunc main() {}\n",
+			wantOK: false,
+			wantErrors: []string{
+				"Not equal (want != got) at %s\n" +
+					"--- testdata/generated.golden\n" +
+					"+++ got\n" +
+					"@@ -1,3 +1,3 @@\n" +
+					"-package main\n" +
+					"+package foo\n" +
+					" \n" +
+					" func main() {}\n",
+			},
+		},
+		{
+			name:   "Matching object",
+			golden: "*dtest_test.Person{Name:\"Alice\",Age:30,}\n",
+			got:    &Person{Name: "Alice", Age: 30},
+			wantOK: true,
+		},
+		{
+			name:   "Changed object",
+			golden: "*dtest_test.Person{Name:\"Alice\",Age:30,}\n",
+			got:    &Person{Name: "Alice", Age: 31},
+			wantOK: false,
+			wantErrors: []string{
+				"Not equal (want != got) at %s\n" +
+					"*dtest_test.Person{Name:\"Alice\",Age:3<(0/1)>,}\n",
+			},
+		},
+		{
+			name:   "Missing golden file",
+			got:    "hello",
+			wantOK: false,
+			wantErrors: []string{
+				"Golden file testdata/generated.golden does not exist; " +
+					"run tests with DIFFATOR_UPDATE_GOLDEN=1 to create it",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inTempDir(t)
+			if tt.golden != "" {
+				writeFile(t, dtest.GoldenPath("generated"), tt.golden)
+			}
+			tb := &fakeTB{}
+			site, ok := callSite(), dtest.Golden(tb, "generated", tt.got)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, atCallSite(tt.wantErrors, site), tb.errors)
+		})
+	}
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	inTempDir(t)
+	*update = true
+	t.Cleanup(func() {
+		*update = false
+	})
+	assert.True(t, dtest.Updating())
+
+	tb := &fakeTB{name: "TestGenerator/main"}
+	assert.True(t, dtest.Golden(tb, "", "package main\n"))
+	assert.Empty(t, tb.errors)
+
+	data, err := os.ReadFile(filepath.Join("testdata", "TestGenerator", "main.golden"))
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n", string(data))
+
+	*update = false
+	assert.True(t, dtest.Golden(tb, "", "package main\n"))
+	assert.False(t, dtest.Golden(tb, "", "package foo\n"))
+}
+
+func TestGoldenUpdateEnvVar(t *testing.T) {
+	inTempDir(t)
+	t.Setenv(dtest.UpdateEnvVar, "1")
+	assert.True(t, dtest.Updating())
+
+	tb := &fakeTB{}
+	assert.True(t, dtest.Golden(tb, "env", []byte("hello\n")))
+	data, err := os.ReadFile(dtest.GoldenPath("env"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello\n", string(data))
+
+	t.Setenv(dtest.UpdateEnvVar, "0")
+	assert.False(t, dtest.Updating())
+}
+
+func TestObsoleteGolden(t *testing.T) {
+	inTempDir(t)
+	writeFile(t, dtest.GoldenPath("used"), "used\n")
+	writeFile(t, dtest.GoldenPath("unused"), "unused\n")
+	writeFile(t, dtest.GoldenPath("sub/unused"), "unused\n")
+	writeFile(t, filepath.Join("testdata", "input.txt"), "not a golden file\n")
+
+	assert.True(t, dtest.Golden(&fakeTB{}, "used", "used\n"))
+
+	obsolete, err := dtest.ObsoleteGolden()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join("testdata", "sub", "unused.golden"),
+		filepath.Join("testdata", "unused.golden"),
+	}, obsolete)
+}
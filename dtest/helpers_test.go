@@ -0,0 +1,80 @@
+package dtest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTB records the calls dtest makes so failures can be tested without
+// failing the test itself. Embedding testing.TB satisfies its unexported
+// method; calling anything not overridden here panics.
+type fakeTB struct {
+	testing.TB
+	name      string
+	helpers   int
+	errors    []string
+	failedNow bool
+}
+
+func (f *fakeTB) Helper() {
+	f.helpers++
+}
+
+func (f *fakeTB) Name() string {
+	return f.name
+}
+
+func (f *fakeTB) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeTB) FailNow() {
+	f.failedNow = true
+}
+
+// callSite returns the base name of the file and the line it is called from,
+// as dtest reports them for an assertion called on the same line.
+func callSite() string {
+	return caller(0)
+}
+
+// nextLine returns the file and line after the one it is called from, as
+// dtest reports them for an assertion called on the next line.
+func nextLine() string {
+	return caller(1)
+}
+
+func caller(offset int) string {
+	_, file, line, _ := runtime.Caller(2)
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line+offset)
+}
+
+// atCallSite returns errors with any %s in each replaced by site.
+func atCallSite(errors []string, site string) (errs []string) {
+	for _, e := range errors {
+		errs = append(errs, strings.ReplaceAll(e, "%s", site))
+	}
+	return errs
+}
+
+// inTempDir runs the test in an empty directory so golden files are read and
+// written in its own testdata/.
+func inTempDir(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
@@ -0,0 +1,75 @@
+package diffator
+
+import (
+	"math"
+)
+
+// floatsEqual returns true if f1 and f2 are equal given the float options and
+// the tolerance of the struct field being compared, if any. bits is the size
+// of the floats compared, 32 or 64, used to measure their distance in ULPs.
+func (o *ObjectComparator) floatsEqual(f1, f2 float64, bits int) (equal bool) {
+	var diff float64
+	opts := o.opts
+
+	if f1 == f2 {
+		equal = true
+		goto end
+	}
+	if math.IsNaN(f1) || math.IsNaN(f2) {
+		equal = opts.NaNEqual && math.IsNaN(f1) && math.IsNaN(f2)
+		goto end
+	}
+	if math.IsInf(f1, 0) || math.IsInf(f2, 0) {
+		goto end
+	}
+	diff = math.Abs(f1 - f2)
+	if diff <= max(opts.FloatAbsEpsilon, o.tag.tolerance) {
+		equal = true
+		goto end
+	}
+	if diff <= opts.FloatRelEpsilon*max(math.Abs(f1), math.Abs(f2)) {
+		equal = true
+		goto end
+	}
+	if opts.FloatMaxULPs == 0 {
+		goto end
+	}
+	equal = ulpDistance(f1, f2, bits) <= opts.FloatMaxULPs
+end:
+	return equal
+}
+
+// complexesEqual returns true if both the real and imaginary parts of c1 and c2
+// are equal as per floatsEqual. bits is the size of the complex numbers, 64 or
+// 128.
+func (o *ObjectComparator) complexesEqual(c1, c2 complex128, bits int) bool {
+	return o.floatsEqual(real(c1), real(c2), bits/2) &&
+		o.floatsEqual(imag(c1), imag(c2), bits/2)
+}
+
+// ulpDistance returns the number of representable floats of size bits between
+// f1 and f2, neither of which may be NaN.
+func ulpDistance(f1, f2 float64, bits int) uint64 {
+	var u1, u2 uint64
+	if bits == 32 {
+		u1 = orderedBits(uint64(math.Float32bits(float32(f1))), 32)
+		u2 = orderedBits(uint64(math.Float32bits(float32(f2))), 32)
+	} else {
+		u1 = orderedBits(math.Float64bits(f1), 64)
+		u2 = orderedBits(math.Float64bits(f2), 64)
+	}
+	if u1 > u2 {
+		return u1 - u2
+	}
+	return u2 - u1
+}
+
+// orderedBits maps the IEEE 754 bits of a float of size bits to an unsigned
+// integer that orders the same as the float, so adjacent floats differ by one.
+func orderedBits(u uint64, bits int) uint64 {
+	sign := uint64(1) << (bits - 1)
+	if u&sign != 0 {
+		return sign - (u &^ sign)
+	}
+	return sign + u
+}
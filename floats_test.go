@@ -0,0 +1,160 @@
+package diffator_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Measurement struct {
+	Value   float64
+	Samples []float32
+	Signal  complex128
+	Labels  map[string]float64
+}
+
+func TestCompareObjectsFloats(t *testing.T) {
+	nan := math.NaN()
+	next := math.Nextafter(1.0, 2.0)
+	point1 := 0.1
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name:     "Exact by default",
+			v1:       point1 + 0.2,
+			v2:       0.3,
+			wantDiff: "(0.30000000000000004!=0.3)",
+		},
+		{
+			name: "Absolute epsilon",
+			v1:   point1 + 0.2,
+			v2:   0.3,
+			opts: &diffator.ObjectOpts{FloatAbsEpsilon: 1e-9},
+		},
+		{
+			name:     "Absolute epsilon exceeded",
+			v1:       1.0,
+			v2:       1.1,
+			opts:     &diffator.ObjectOpts{FloatAbsEpsilon: 1e-9},
+			wantDiff: "(1!=1.1)",
+		},
+		{
+			name: "Relative epsilon",
+			v1:   1e9,
+			v2:   1e9 + 1,
+			opts: &diffator.ObjectOpts{FloatRelEpsilon: 1e-6},
+		},
+		{
+			name:     "Relative epsilon exceeded",
+			v1:       1e-3,
+			v2:       2e-3,
+			opts:     &diffator.ObjectOpts{FloatRelEpsilon: 1e-6},
+			wantDiff: "(0.001!=0.002)",
+		},
+		{
+			name: "ULPs",
+			v1:   1.0,
+			v2:   next,
+			opts: &diffator.ObjectOpts{FloatMaxULPs: 1},
+		},
+		{
+			name: "ULPs across zero",
+			v1:   math.SmallestNonzeroFloat64,
+			v2:   -math.SmallestNonzeroFloat64,
+			opts: &diffator.ObjectOpts{FloatMaxULPs: 2},
+		},
+		{
+			name:     "ULPs exceeded",
+			v1:       1.0,
+			v2:       math.Nextafter(next, 2.0),
+			opts:     &diffator.ObjectOpts{FloatMaxULPs: 1},
+			wantDiff: "(1!=1.0000000000000004)",
+		},
+		{
+			name: "Float32 ULPs",
+			v1:   float32(1.0),
+			v2:   math.Nextafter32(1.0, 2.0),
+			opts: &diffator.ObjectOpts{FloatMaxULPs: 1},
+		},
+		{
+			name:     "NaN differs by default",
+			v1:       nan,
+			v2:       nan,
+			wantDiff: "(NaN!=NaN)",
+		},
+		{
+			name: "NaN equal",
+			v1:   nan,
+			v2:   nan,
+			opts: &diffator.ObjectOpts{NaNEqual: true},
+		},
+		{
+			name:     "NaN vs number",
+			v1:       nan,
+			v2:       1.0,
+			opts:     &diffator.ObjectOpts{NaNEqual: true, FloatAbsEpsilon: math.Inf(1)},
+			wantDiff: "(NaN!=1)",
+		},
+		{
+			name:     "Infinity vs max",
+			v1:       math.Inf(1),
+			v2:       math.MaxFloat64,
+			opts:     &diffator.ObjectOpts{FloatRelEpsilon: 1},
+			wantDiff: "(+Inf!=1.7976931348623157e+308)",
+		},
+		{
+			name: "Complex",
+			v1:   complex(0.1+0.2, 1.0),
+			v2:   complex(0.3, 1.0+1e-12),
+			opts: &diffator.ObjectOpts{FloatAbsEpsilon: 1e-9},
+		},
+		{
+			name:     "Complex exceeded",
+			v1:       complex(1, 1),
+			v2:       complex(1, 2),
+			opts:     &diffator.ObjectOpts{FloatAbsEpsilon: 1e-9},
+			wantDiff: "complex128{imag:(1!=2),}",
+		},
+		{
+			name: "Nested",
+			v1: &Measurement{
+				Value:   nan,
+				Samples: []float32{0.1, 0.2},
+				Signal:  complex(nan, 0),
+				Labels:  map[string]float64{"a": point1 + 0.2},
+			},
+			v2: &Measurement{
+				Value:   nan,
+				Samples: []float32{0.1 + 1e-9, 0.2},
+				Signal:  complex(nan, 0),
+				Labels:  map[string]float64{"a": 0.3},
+			},
+			opts: &diffator.ObjectOpts{NaNEqual: true, FloatAbsEpsilon: 1e-6},
+		},
+		{
+			name: "Nested exceeded",
+			v1: &Measurement{
+				Samples: []float32{0.1, 0.2},
+				Labels:  map[string]float64{"a": 0.1},
+			},
+			v2: &Measurement{
+				Samples: []float32{0.1, 0.3},
+				Labels:  map[string]float64{"a": 0.2},
+			},
+			opts:     &diffator.ObjectOpts{FloatAbsEpsilon: 1e-6},
+			wantDiff: "*diffator_test.Measurement{Samples:[]float32{[1](0.20000000298023224!=0.30000001192092896),},Labels:map[string]float64{a:(0.1!=0.2),},}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package diffator
+
+import (
+	"fmt"
+	"go/format"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var byteSliceType = reflect.TypeOf([]byte(nil))
+
+// GoString returns the reflector's value as Go syntax; see AsGoSyntax(). It
+// implements fmt.GoStringer, so the value is also printed by `%#v`.
+func (r *Reflector) GoString() string {
+	return r.AsGoSyntax(r.Value)
+}
+
+// AsGoSyntax returns rv as a Go expression that evaluates to an equal value,
+// formatted by gofmt, so it can be pasted into a test as the value wanted:
+//
+//	&pkg.User{
+//		Name:  "Alice",
+//		Roles: []string{"admin"},
+//	}
+//
+// Struct fields with zero values are omitted, map entries are sorted by key,
+// and numbers are converted to their type where it would not be inferred.
+// Types declared in the package named by Reflector.Package are written without
+// a package qualifier. Values with no Go syntax, such as non-nil funcs and
+// recursive references, are written as nil followed by a comment.
+func (r *Reflector) AsGoSyntax(rv *reflect.Value) string {
+	sb := strings.Builder{}
+	r.writeGoSyntax(&sb, *rv, false)
+	src := "var _ = " + sb.String()
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return sb.String()
+	}
+	return strings.TrimPrefix(string(formatted), "var _ = ")
+}
+
+// writeGoSyntax writes rv as Go syntax to sb. typed is true when the type of
+// rv is implied by where it is written, e.g. the elements of a []int, and false
+// when it is not, e.g. the elements of an []any.
+func (r *Reflector) writeGoSyntax(sb *strings.Builder, rv reflect.Value, typed bool) {
+	if !rv.IsValid() {
+		sb.WriteString("nil")
+		return
+	}
+	seen, id := r.tracker.Push(&rv)
+	if seen && isReference(rv.Kind()) {
+		sb.WriteString("nil /* recursion */")
+		return
+	}
+	defer r.tracker.Pop(id)
+
+	rt := rv.Type()
+	if s, ok := goStringOf(rv); ok {
+		sb.WriteString(s)
+		return
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		r.writeConversion(sb, rt, strconv.FormatBool(rv.Bool()), typed || rt == reflect.TypeOf(false))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		r.writeConversion(sb, rt, strconv.FormatInt(rv.Int(), 10), typed || rt == reflect.TypeOf(0))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		r.writeConversion(sb, rt, strconv.FormatUint(rv.Uint(), 10), typed)
+	case reflect.Float32, reflect.Float64:
+		r.writeFloat(sb, rt, rv.Float(), typed)
+	case reflect.Complex64, reflect.Complex128:
+		r.writeComplex(sb, rt, rv.Complex(), typed)
+	case reflect.String:
+		r.writeConversion(sb, rt, strconv.Quote(rv.String()), typed || rt == reflect.TypeOf(""))
+	case reflect.Interface:
+		r.writeGoSyntax(sb, rv.Elem(), false)
+	case reflect.Pointer:
+		r.writePointer(sb, rv, typed)
+	case reflect.Slice:
+		switch {
+		case rv.IsNil():
+			r.writeNil(sb, rt, typed)
+		case rt.Elem().Kind() == reflect.Uint8:
+			r.writeConversion(sb, rt, strconv.Quote(string(rv.Bytes())), false)
+		default:
+			r.writeElements(sb, rv)
+		}
+	case reflect.Array:
+		r.writeElements(sb, rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			r.writeNil(sb, rt, typed)
+			break
+		}
+		r.writeMap(sb, rv)
+	case reflect.Struct:
+		r.writeStruct(sb, rv)
+	case reflect.Chan:
+		if rv.IsNil() {
+			r.writeNil(sb, rt, typed)
+			break
+		}
+		fmt.Fprintf(sb, "make(%s, %d)", r.goTypeName(rt), rv.Cap())
+	case reflect.Func, reflect.UnsafePointer:
+		r.writeNil(sb, rt, typed)
+		if !rv.IsNil() {
+			fmt.Fprintf(sb, " /* %s */", rv.Kind())
+		}
+	default:
+		panicf("Unhandled (as of yet) reflect value kind: %s", rv.Kind())
+	}
+}
+
+// goStringOf returns the result of rv's GoString method, if it has one.
+func goStringOf(rv reflect.Value) (s string, ok bool) {
+	var a any
+	var gs fmt.GoStringer
+
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			goto end
+		}
+	}
+	a, ok = interfaceOf(&rv)
+	if !ok {
+		goto end
+	}
+	gs, ok = a.(fmt.GoStringer)
+	if !ok {
+		goto end
+	}
+	s = gs.GoString()
+end:
+	return s, ok
+}
+
+// writeConversion writes literal, converted to rt unless typed.
+func (r *Reflector) writeConversion(sb *strings.Builder, rt reflect.Type, literal string, typed bool) {
+	if typed {
+		sb.WriteString(literal)
+		return
+	}
+	fmt.Fprintf(sb, "%s(%s)", r.goTypeName(rt), literal)
+}
+
+func (r *Reflector) writeNil(sb *strings.Builder, rt reflect.Type, typed bool) {
+	if typed {
+		sb.WriteString("nil")
+		return
+	}
+	fmt.Fprintf(sb, "(%s)(nil)", r.goTypeName(rt))
+}
+
+// writeFloat writes f, which must be written as a float literal such as `1.0`
+// rather than `1` if it is an untyped float64.
+func (r *Reflector) writeFloat(sb *strings.Builder, rt reflect.Type, f float64, typed bool) {
+	var s string
+	isFloat64 := rt == reflect.TypeOf(0.0)
+	switch {
+	case math.IsNaN(f):
+		s = "math.NaN()"
+		typed = isFloat64
+	case math.IsInf(f, 0):
+		s = fmt.Sprintf("math.Inf(%d)", int(math.Copysign(1, f)))
+		typed = isFloat64
+	default:
+		s = strconv.FormatFloat(f, 'g', -1, rt.Bits())
+		if isFloat64 && !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		typed = typed || isFloat64
+	}
+	r.writeConversion(sb, rt, s, typed)
+}
+
+func (r *Reflector) writeComplex(sb *strings.Builder, rt reflect.Type, c complex128, typed bool) {
+	var s string
+	isComplex128 := rt == reflect.TypeOf(complex128(0))
+	if math.IsNaN(real(c)) || math.IsInf(real(c), 0) || math.IsNaN(imag(c)) || math.IsInf(imag(c), 0) {
+		sbParts := strings.Builder{}
+		float64Type := reflect.TypeOf(0.0)
+		sbParts.WriteString("complex(")
+		r.writeFloat(&sbParts, float64Type, real(c), true)
+		sbParts.WriteString(", ")
+		r.writeFloat(&sbParts, float64Type, imag(c), true)
+		sbParts.WriteString(")")
+		r.writeConversion(sb, rt, sbParts.String(), isComplex128)
+		return
+	}
+	s = strconv.FormatComplex(c, 'g', -1, rt.Bits())
+	r.writeConversion(sb, rt, s, typed || isComplex128)
+}
+
+// writePointer writes a pointer as the address of a composite literal, e.g.
+// `&T{...}`, or for other values as the address of the element of a slice,
+// e.g. `&[]int{42}[0]`.
+func (r *Reflector) writePointer(sb *strings.Builder, rv reflect.Value, typed bool) {
+	rt := rv.Type()
+	if rv.IsNil() {
+		r.writeNil(sb, rt, typed)
+		return
+	}
+	elem := rv.Elem()
+	switch elem.Kind() {
+	case reflect.Struct, reflect.Array:
+		if _, ok := goStringOf(elem); ok {
+			break
+		}
+		sb.WriteByte('&')
+		r.writeGoSyntax(sb, elem, false)
+		return
+	case reflect.Slice, reflect.Map:
+		if elem.IsNil() || rt.Elem() == byteSliceType {
+			break
+		}
+		sb.WriteByte('&')
+		r.writeGoSyntax(sb, elem, false)
+		return
+	}
+	fmt.Fprintf(sb, "&[]%s{", r.goTypeName(rt.Elem()))
+	r.writeGoSyntax(sb, elem, rt.Elem().Kind() != reflect.Interface)
+	sb.WriteString("}[0]")
+}
+
+// writeElements writes a slice or array literal, on one line if its elements
+// are of a basic type such as int or string, or else one element per line.
+func (r *Reflector) writeElements(sb *strings.Builder, rv reflect.Value) {
+	rt := rv.Type()
+	typed := rt.Elem().Kind() != reflect.Interface
+	oneLine := isBasicKind(rt.Elem().Kind())
+	sb.WriteString(r.goTypeName(rt))
+	sb.WriteByte('{')
+	for i := 0; i < rv.Len(); i++ {
+		switch {
+		case !oneLine:
+			sb.WriteByte('\n')
+		case i > 0:
+			sb.WriteString(", ")
+		}
+		r.writeGoSyntax(sb, rv.Index(i), typed)
+		if !oneLine {
+			sb.WriteByte(',')
+		}
+	}
+	if rv.Len() > 0 && !oneLine {
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('}')
+}
+
+// writeMap writes a map literal with one entry per line, sorted by key.
+func (r *Reflector) writeMap(sb *strings.Builder, rv reflect.Value) {
+	rt := rv.Type()
+	keyTyped := rt.Key().Kind() != reflect.Interface
+	elemTyped := rt.Elem().Kind() != reflect.Interface
+	sb.WriteString(r.goTypeName(rt))
+	sb.WriteByte('{')
+	if rv.Len() > 0 {
+		sb.WriteByte('\n')
+	}
+	for _, key := range SortedMapKeys(rv) {
+		r.writeGoSyntax(sb, key, keyTyped)
+		sb.WriteString(": ")
+		r.writeGoSyntax(sb, rv.MapIndex(key), elemTyped)
+		sb.WriteString(",\n")
+	}
+	sb.WriteByte('}')
+}
+
+// isBasicKind returns true for the kinds of Go's predeclared basic types.
+func isBasicKind(kind reflect.Kind) (basic bool) {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		basic = true
+	}
+	return basic
+}
+
+// writeStruct writes the fields of rv that do not have zero values.
+func (r *Reflector) writeStruct(sb *strings.Builder, rv reflect.Value) {
+	rt := rv.Type()
+	sb.WriteString(r.goTypeName(rt))
+	sb.WriteByte('{')
+	newline := false
+	for i := 0; i < rv.NumField(); i++ {
+		fld := rv.Field(i)
+		if fld.IsZero() {
+			continue
+		}
+		if !newline {
+			sb.WriteByte('\n')
+			newline = true
+		}
+		sb.WriteString(rt.Field(i).Name)
+		sb.WriteString(": ")
+		r.writeGoSyntax(sb, fld, rt.Field(i).Type.Kind() != reflect.Interface)
+		sb.WriteString(",\n")
+	}
+	sb.WriteByte('}')
+}
+
+// goTypeName returns the name of rt as written in Go source, omitting the
+// package qualifier for types declared in the package named by r.Package.
+func (r *Reflector) goTypeName(rt reflect.Type) (name string) {
+	if rt == byteSliceType {
+		name = "[]byte"
+		goto end
+	}
+	if rt.Name() != "" {
+		name = rt.String()
+		if r.Package != "" {
+			name = strings.TrimPrefix(name, r.Package+".")
+		}
+		goto end
+	}
+	switch rt.Kind() {
+	case reflect.Pointer:
+		name = "*" + r.goTypeName(rt.Elem())
+	case reflect.Slice:
+		name = "[]" + r.goTypeName(rt.Elem())
+	case reflect.Array:
+		name = fmt.Sprintf("[%d]%s", rt.Len(), r.goTypeName(rt.Elem()))
+	case reflect.Map:
+		name = fmt.Sprintf("map[%s]%s", r.goTypeName(rt.Key()), r.goTypeName(rt.Elem()))
+	case reflect.Chan:
+		switch rt.ChanDir() {
+		case reflect.RecvDir:
+			name = "<-chan " + r.goTypeName(rt.Elem())
+		case reflect.SendDir:
+			name = "chan<- " + r.goTypeName(rt.Elem())
+		default:
+			name = "chan " + r.goTypeName(rt.Elem())
+		}
+	case reflect.Interface:
+		name = rt.String()
+		if rt.NumMethod() == 0 {
+			name = "any"
+		}
+	default:
+		name = rt.String()
+	}
+end:
+	return name
+}
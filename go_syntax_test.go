@@ -0,0 +1,146 @@
+package diffator_test
+
+import (
+	"go/parser"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Celsius float64
+
+type Document struct {
+	Title   string
+	Version int8
+	Temp    Celsius
+	Body    []byte
+	Tags    []string
+	Meta    map[string]any
+	Parent  *Document
+	Created time.Time
+	Rating  *float64
+	Notify  func()
+	count   uint
+}
+
+func TestReflectorGoSyntax(t *testing.T) {
+	rating := 4.5
+	recur := &Recur1Struct{Name: "Test"}
+	recur.Child = recur
+	tests := []struct {
+		name   string
+		value  any
+		pkg    string
+		wantGo string
+	}{
+		{
+			name:  "Default types",
+			value: []any{1, 1.0, 2.5, "x", true, complex(1, 2), nil},
+			wantGo: `[]any{
+	1,
+	1.0,
+	2.5,
+	"x",
+	true,
+	(1 + 2i),
+	nil,
+}`,
+		},
+		{
+			name:  "Typed conversions",
+			value: []any{int8(1), uint(2), float32(1.5), Celsius(20), complex64(0), byte('a')},
+			pkg:   "diffator_test",
+			wantGo: `[]any{
+	int8(1),
+	uint(2),
+	float32(1.5),
+	Celsius(20),
+	complex64((0 + 0i)),
+	uint8(97),
+}`,
+		},
+		{
+			name:  "Special floats",
+			value: []any{math.NaN(), math.Inf(-1), float32(math.Inf(1))},
+			wantGo: `[]any{
+	math.NaN(),
+	math.Inf(-1),
+	float32(math.Inf(1)),
+}`,
+		},
+		{
+			name:  "Nil values",
+			value: []any{[]int(nil), map[string]int(nil), (*Document)(nil), (chan<- int)(nil)},
+			wantGo: `[]any{
+	([]int)(nil),
+	(map[string]int)(nil),
+	(*diffator_test.Document)(nil),
+	(chan<- int)(nil),
+}`,
+		},
+		{
+			name:   "Pointer to scalar",
+			value:  &rating,
+			wantGo: `&[]float64{4.5}[0]`,
+		},
+		{
+			name:   "Recursion",
+			value:  recur,
+			pkg:    "diffator_test",
+			wantGo: "&Recur1Struct{\n\tName:  \"Test\",\n\tChild: nil, /* recursion */\n}",
+		},
+		{
+			name: "Struct",
+			value: &Document{
+				Title:   "Notes",
+				Version: 2,
+				Temp:    21.5,
+				Body:    []byte("Hello\n\x00"),
+				Tags:    []string{"a", "b"},
+				Meta: map[string]any{
+					"z":     uint8(1),
+					"a":     []int{1},
+					"empty": map[int]bool{},
+				},
+				Parent:  &Document{Title: "Root"},
+				Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Rating:  &rating,
+				Notify:  func() {},
+				count:   3,
+			},
+			pkg: "diffator_test",
+			wantGo: `&Document{
+	Title:   "Notes",
+	Version: 2,
+	Temp:    21.5,
+	Body:    []byte("Hello\n\x00"),
+	Tags:    []string{"a", "b"},
+	Meta: map[string]any{
+		"a":     []int{1},
+		"empty": map[int]bool{},
+		"z":     uint8(1),
+	},
+	Parent: &Document{
+		Title: "Root",
+	},
+	Created: time.Date(2024, time.January, 2, 3, 4, 5, 0, time.UTC),
+	Rating:  &[]float64{4.5}[0],
+	Notify:  nil, /* func */
+	count:   3,
+}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := diffator.NewReflector(tt.value)
+			r.Package = tt.pkg
+			got := r.GoString()
+			assert.Equal(t, tt.wantGo, got)
+			_, err := parser.ParseExpr(got)
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,24 @@
+package diffator
+
+type HTMLOpts struct {
+	// Title heads the report and names it in the browser.
+	Title *StringValue
+	// Collapsible renders the differences within nested objects as a tree
+	// whose structs, slices and maps can be collapsed and expanded.
+	Collapsible *BoolValue
+	// OmitEqual leaves comparisons that found no differences out of the
+	// report, other than in its counts.
+	OmitEqual *BoolValue
+}
+
+func (opts *HTMLOpts) SetDefaults() {
+	if opts.Title == nil {
+		opts.Title = String("Diffator Report")
+	}
+	if opts.Collapsible == nil {
+		opts.Collapsible = Bool(true)
+	}
+	if opts.OmitEqual == nil {
+		opts.OmitEqual = Bool(false)
+	}
+}
@@ -0,0 +1,415 @@
+package diffator
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// htmlStyle is the stylesheet inlined into every report, so that it can be
+// opened offline and archived as a single file.
+const htmlStyle = `
+body{font:14px/1.4 system-ui,sans-serif;margin:2em;color:#222}
+h1{font-size:1.5em;margin:0 0 .5em}
+h2{font-size:1.1em;margin:.8em 0}
+nav ol{margin:.5em 0}
+section{border:1px solid #ddd;border-radius:4px;margin:1em 0;padding:0 1em 1em}
+.status{font-size:.85em;font-weight:normal;margin-left:.5em}
+.differ>h2 .status,.summary .differ{color:#b00}
+.equal>h2 .status{color:#080}
+pre,table.lines,.tree{font:13px/1.4 ui-monospace,Menlo,Consolas,monospace}
+pre{margin:0;white-space:pre-wrap}
+del{background:#fdd;color:#900;text-decoration:none}
+ins{background:#dfd;color:#060;text-decoration:none}
+table.lines{border-collapse:collapse;width:100%}
+table.lines td{padding:0 .5em;white-space:pre-wrap;vertical-align:top}
+table.lines td.marker{width:1em;color:#888}
+tr.del{background:#fee}
+tr.ins{background:#efe}
+tr.changed td.left{background:#fee}
+tr.changed td.right{background:#efe}
+tr.elided td{color:#888}
+.tree ul{list-style:none;margin:0;padding-left:1.5em}
+.tree summary{cursor:pointer}
+.path{font-weight:bold}
+.type,.kind{color:#888}
+`
+
+// HTMLReport aggregates comparisons, such as those of a test run, into a
+// self-contained HTML page that needs no external assets, with a section per
+// comparison and links to those that found differences. It is safe for
+// concurrent use, e.g. by parallel tests.
+type HTMLReport struct {
+	opts    *HTMLOpts
+	mu      sync.Mutex
+	entries []htmlEntry
+}
+
+// htmlEntry is a comparison added to an HTMLReport, rendered when added so the
+// values compared can change afterward.
+type htmlEntry struct {
+	name   string
+	differ bool
+	body   string
+}
+
+func NewHTMLReport(opts *HTMLOpts) *HTMLReport {
+	if opts == nil {
+		opts = &HTMLOpts{}
+	}
+	opts.SetDefaults()
+	return &HTMLReport{opts: opts}
+}
+
+// AddStrings compares s1 (want) with s2 (got) as CompareStrings() does, adds
+// the comparison to the report under name, and returns true if they are
+// equal. Strings with more than one line, or compared in LineMode, Unified or
+// SideBySide, are shown line-by-line, and other strings inline.
+func (r *HTMLReport) AddStrings(name, s1, s2 string, opts *StringOpts) (equal bool) {
+	equal = s1 == s2
+	r.add(htmlEntry{
+		name:   name,
+		differ: !equal,
+		body:   htmlStringDiff(s1, s2, opts),
+	})
+	return equal
+}
+
+// AddObjects compares v1 (want) with v2 (got) as CompareObjects() does, adds
+// the comparison to the report under name, and returns true if they are
+// equal. The differences are shown as a tree of the paths where they were
+// found.
+func (r *HTMLReport) AddObjects(name string, v1, v2 any, opts *ObjectOpts) (equal bool) {
+	c := NewObjectComparator(v1, v2, opts)
+	d := c.Result()
+	equal = d == nil
+	r.add(htmlEntry{
+		name:   name,
+		differ: !equal,
+		body:   newHTMLTree(c, r.opts).render(d),
+	})
+	return equal
+}
+
+func (r *HTMLReport) add(e htmlEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, e)
+}
+
+// Differences returns the number of comparisons added that found differences.
+func (r *HTMLReport) Differences() (n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.entries {
+		if e.differ {
+			n++
+		}
+	}
+	return n
+}
+
+// String returns the report as an HTML document.
+func (r *HTMLReport) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.render()
+}
+
+// WriteTo writes the report as an HTML document to w.
+func (r *HTMLReport) WriteTo(w io.Writer) (n int64, err error) {
+	return io.Copy(w, strings.NewReader(r.String()))
+}
+
+// WriteFile writes the report as an HTML document to the file name, e.g. to
+// archive it as a CI artifact.
+func (r *HTMLReport) WriteFile(name string) error {
+	return os.WriteFile(name, []byte(r.String()), 0o644)
+}
+
+func (r *HTMLReport) render() string {
+	var differ int
+
+	for _, e := range r.entries {
+		if e.differ {
+			differ++
+		}
+	}
+	title := html.EscapeString(r.opts.Title.Value)
+	sb := strings.Builder{}
+	sb.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString(fmt.Sprintf("<title>%s</title>\n<style>%s</style>\n</head>\n<body>\n", title, htmlStyle))
+	sb.WriteString(fmt.Sprintf("<h1>%s</h1>\n", title))
+	sb.WriteString(fmt.Sprintf("<p class=\"summary\">%d comparisons, <span class=\"differ\">%d with differences</span></p>\n",
+		len(r.entries), differ,
+	))
+	if differ > 0 && len(r.entries) > 1 {
+		sb.WriteString("<nav>\n<ol>\n")
+		for i, e := range r.entries {
+			if e.differ {
+				sb.WriteString(fmt.Sprintf("<li><a href=\"#c%d\">%s</a></li>\n", i+1, html.EscapeString(e.name)))
+			}
+		}
+		sb.WriteString("</ol>\n</nav>\n")
+	}
+	for i, e := range r.entries {
+		if !e.differ && r.opts.OmitEqual.Value {
+			continue
+		}
+		class, status := "equal", "equal"
+		if e.differ {
+			class, status = "differ", "differs"
+		}
+		sb.WriteString(fmt.Sprintf("<section id=\"c%d\" class=\"%s\">\n<h2>%s<span class=\"status\">%s</span></h2>\n",
+			i+1, class, html.EscapeString(e.name), status,
+		))
+		if e.differ {
+			sb.WriteString(e.body)
+		}
+		sb.WriteString("</section>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String()
+}
+
+// CompareStringsHTML compares s1 (want) with s2 (got) and returns a
+// self-contained HTML document showing their differences; see
+// HTMLReport.AddStrings().
+func CompareStringsHTML(s1, s2 string, opts *StringOpts, htmlOpts *HTMLOpts) string {
+	r := NewHTMLReport(htmlOpts)
+	r.AddStrings("Strings", s1, s2, opts)
+	return r.String()
+}
+
+// CompareObjectsHTML compares v1 (want) with v2 (got) and returns a
+// self-contained HTML document showing their differences; see
+// HTMLReport.AddObjects().
+func CompareObjectsHTML(v1, v2 any, opts *ObjectOpts, htmlOpts *HTMLOpts) string {
+	r := NewHTMLReport(htmlOpts)
+	r.AddObjects("Objects", v1, v2, opts)
+	return r.String()
+}
+
+// htmlStringDiff renders the differences between s1 and s2 as HTML, inline
+// with <del> and <ins> for single lines, or else as a table of lines.
+func htmlStringDiff(s1, s2 string, opts *StringOpts) (s string) {
+	var o StringOpts
+	var segs []segment
+
+	if s1 == s2 {
+		goto end
+	}
+	if opts != nil {
+		o = *opts
+	}
+	o.SetDefaults()
+	if !o.lineMode() && (strings.Contains(s1, "\n") || strings.Contains(s2, "\n")) {
+		o.LineMode = Bool(true)
+	}
+	segs = NewStringComparator(s1, s2, &o).compare().segments()
+	switch {
+	case o.SideBySide.Value:
+		s = o.htmlSideBySide(segs)
+	case o.lineMode():
+		s = o.htmlLines(segs)
+	default:
+		s = "<pre>" + htmlSegments(segs) + "</pre>\n"
+	}
+end:
+	return s
+}
+
+// htmlSegments renders segments inline, the left segments as <del> and the
+// right as <ins>.
+func htmlSegments(segs []segment) string {
+	sb := strings.Builder{}
+	for _, seg := range segs {
+		text := html.EscapeString(seg.text)
+		switch seg.kind {
+		case leftSegment:
+			sb.WriteString("<del>" + text + "</del>")
+		case rightSegment:
+			sb.WriteString("<ins>" + text + "</ins>")
+		default:
+			sb.WriteString(text)
+		}
+	}
+	return sb.String()
+}
+
+// htmlLines renders segments as a table with a row per line, as renderLines()
+// does.
+func (opts *StringOpts) htmlLines(segs []segment) string {
+	var elided bool
+
+	lines := toLines(segs)
+	show, _ := opts.contextMask(lines)
+	sb := strings.Builder{}
+	sb.WriteString("<table class=\"lines\">\n")
+	for i, line := range lines {
+		if !show[i] {
+			if !elided {
+				sb.WriteString("<tr class=\"elided\"><td class=\"marker\"></td><td>" + elidedLines + "</td></tr>\n")
+			}
+			elided = true
+			continue
+		}
+		elided = false
+		class := "both"
+		switch line.kind {
+		case leftSegment:
+			class = "del"
+		case rightSegment:
+			class = "ins"
+		}
+		sb.WriteString(fmt.Sprintf("<tr class=\"%s\"><td class=\"marker\">%s</td><td>%s</td></tr>\n",
+			class, line.prefix(), html.EscapeString(line.text),
+		))
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
+
+// htmlSideBySide renders segments as a table with want on the left and got on
+// the right, as renderSideBySide() does. Lines wrap to fit the page rather than
+// Width.
+func (opts *StringOpts) htmlSideBySide(segs []segment) string {
+	var elided bool
+
+	rows := sideBySideRows(segs)
+	show, _ := opts.contextMaskOf(len(rows), func(i int) bool {
+		return rows[i].marker != bothMarker
+	})
+	sb := strings.Builder{}
+	sb.WriteString("<table class=\"lines\">\n")
+	for i, row := range rows {
+		if !show[i] {
+			if !elided {
+				sb.WriteString("<tr class=\"elided\"><td>" + elidedLines + "</td><td class=\"marker\"></td><td></td></tr>\n")
+			}
+			elided = true
+			continue
+		}
+		elided = false
+		class := "both"
+		switch row.marker {
+		case leftMarker:
+			class = "del"
+		case rightMarker:
+			class = "ins"
+		case changedMarker:
+			class = "changed"
+		}
+		sb.WriteString(fmt.Sprintf("<tr class=\"%s\"><td class=\"left\">%s</td><td class=\"marker\">%s</td><td class=\"right\">%s</td></tr>\n",
+			class, html.EscapeString(row.left), html.EscapeString(row.marker), html.EscapeString(row.right),
+		))
+	}
+	sb.WriteString("</table>\n")
+	return sb.String()
+}
+
+// htmlTree renders a Diff as nested lists of the paths where differences were
+// found, collapsible per HTMLOpts.
+type htmlTree struct {
+	*ObjectComparator
+	opts *HTMLOpts
+	sb   strings.Builder
+}
+
+func newHTMLTree(c *ObjectComparator, opts *HTMLOpts) *htmlTree {
+	return &htmlTree{ObjectComparator: c, opts: opts}
+}
+
+func (t *htmlTree) render(d *Diff) string {
+	if d == nil {
+		return ""
+	}
+	t.sb.WriteString("<div class=\"tree\">\n")
+	t.writeNode(d, "")
+	t.sb.WriteString("</div>\n")
+	return t.sb.String()
+}
+
+// writeNode writes d labeled with label, as a container of its children or as
+// a leaf with its want and got values.
+func (t *htmlTree) writeNode(d *Diff, label string) {
+	typ := "nil"
+	switch {
+	case d.Want.IsValid():
+		typ = d.Want.Type().String()
+	case d.Got.IsValid():
+		typ = d.Got.Type().String()
+	}
+	// The value a pointer or interface refers to is shown as the pointer
+	for len(d.Children) == 1 && len(d.Children[0].Path) == len(d.Path) && !d.Redacted {
+		d = d.Children[0]
+	}
+	title := d.Path.String()
+	if title == "" {
+		title, label = "<root>", "<root>"
+	}
+	head := fmt.Sprintf("<span class=\"path\" title=\"%s\">%s</span> <span class=\"type\">%s</span>",
+		html.EscapeString(title), html.EscapeString(label), html.EscapeString(typ),
+	)
+	if len(d.Children) == 0 || d.Redacted {
+		t.sb.WriteString(head + " " + strings.TrimSuffix(t.leaf(d), "\n") + "\n")
+		return
+	}
+	if t.opts.Collapsible.Value {
+		t.sb.WriteString("<details open>\n<summary>" + head + "</summary>\n")
+	} else {
+		t.sb.WriteString("<div>" + head + "</div>\n")
+	}
+	t.sb.WriteString("<ul>\n")
+	for _, child := range d.Children {
+		t.sb.WriteString("<li>")
+		t.writeNode(child, strings.TrimPrefix(child.Path.Last().String(), "."))
+		t.sb.WriteString("</li>\n")
+	}
+	t.sb.WriteString("</ul>\n")
+	if t.opts.Collapsible.Value {
+		t.sb.WriteString("</details>\n")
+	}
+}
+
+// leaf renders the want and got values of leaf d.
+func (t *htmlTree) leaf(d *Diff) (s string) {
+	switch {
+	case d.Kind == MissingExpectedDiff:
+		s = "<del>" + htmlValue(d.Want) + "</del> <span class=\"kind\">deleted</span>"
+	case d.Kind == MissingActualDiff:
+		s = "<ins>" + htmlValue(d.Got) + "</ins> <span class=\"kind\">inserted</span>"
+	case d.Redacted:
+		s = "<del>" + html.EscapeString(Redacted) + "</del> <ins>" + html.EscapeString(Redacted) + "</ins>"
+	case d.Kind == ChangedDiff && d.Want.Kind() == reflect.String && d.Got.Kind() == reflect.String:
+		s = htmlStringDiff(d.Want.String(), d.Got.String(), t.stringOpts())
+	case d.Kind == ChangedDiff && d.Want.Type() == d.Got.Type() && isBytesType(d.Want.Type()):
+		s = t.bytesLeaf(d.Want, d.Got)
+	default:
+		s = "<del>" + htmlValue(d.Want) + "</del> <ins>" + htmlValue(d.Got) + "</ins>"
+	}
+	return s
+}
+
+// bytesLeaf renders the differences between two slices or arrays of bytes as
+// text if both are text, or else as a hex dump, as renderBytes() does.
+func (t *htmlTree) bytesLeaf(rv1, rv2 reflect.Value) string {
+	b1, b2 := bytesOf(rv1), bytesOf(rv2)
+	if isText(b1) && isText(b2) {
+		return htmlStringDiff(string(b1), string(b2), t.stringOpts())
+	}
+	return "<pre>" + html.EscapeString(hexDump(b1, b2, "", plainStyler{})) + "</pre>"
+}
+
+// htmlValue returns rv as escaped text, with the fields tagged
+// `diff:"redact"` of the structs within it masked.
+func htmlValue(rv reflect.Value) string {
+	if !rv.IsValid() {
+		return "nil"
+	}
+	return html.EscapeString(opaqueString(rv))
+}
@@ -0,0 +1,190 @@
+package diffator_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareStringsHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		s1       string
+		s2       string
+		opts     *diffator.StringOpts
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "Inline",
+			s1:   "Hello <World>",
+			s2:   "Hello <There>",
+			contains: []string{
+				"<pre>Hello &lt;<del>World</del><ins>There</ins>&gt;</pre>",
+			},
+		},
+		{
+			name: "Lines",
+			s1:   "a\nb\nc\n",
+			s2:   "a\nB\nc\n",
+			contains: []string{
+				`<tr class="both"><td class="marker"> </td><td>a</td></tr>`,
+				`<tr class="del"><td class="marker">-</td><td>b</td></tr>`,
+				`<tr class="ins"><td class="marker">+</td><td>B</td></tr>`,
+			},
+		},
+		{
+			name: "Context lines",
+			s1:   "1\n2\n3\n4\n5\n",
+			s2:   "1\n2\nthree\n4\n5\n",
+			opts: &diffator.StringOpts{ContextLines: diffator.Int(0)},
+			contains: []string{
+				`<tr class="elided"><td class="marker"></td><td>...</td></tr>`,
+				`<tr class="ins"><td class="marker">+</td><td>three</td></tr>`,
+			},
+			excludes: []string{"<td>1</td>", "<td>5</td>"},
+		},
+		{
+			name: "Side-by-side",
+			s1:   "port = 80\nuser = admin\n",
+			s2:   "port = 8080\n",
+			opts: &diffator.StringOpts{SideBySide: diffator.Bool(true)},
+			contains: []string{
+				`<tr class="changed"><td class="left">port = 80</td><td class="marker">|</td><td class="right">port = 8080</td></tr>`,
+				`<tr class="del"><td class="left">user = admin</td><td class="marker">&lt;</td><td class="right"></td></tr>`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := diffator.CompareStringsHTML(tt.s1, tt.s2, tt.opts, nil)
+			assertSelfContained(t, doc)
+			for _, s := range tt.contains {
+				assert.Contains(t, doc, s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, doc, s)
+			}
+		})
+	}
+}
+
+func TestCompareObjectsHTML(t *testing.T) {
+	c1, c2 := newSideBySideConfig(), newSideBySideConfig()
+	c2.Port = 8080
+	c2.Tags = append(c2.Tags, "<c>")
+	c2.Owner.Email = "b@x"
+	c2.Password = "hunter2"
+	c2.Internal = "y"
+
+	tests := []struct {
+		name     string
+		htmlOpts *diffator.HTMLOpts
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "Collapsible",
+			contains: []string{
+				`<details open>`,
+				`<summary><span class="path" title="&lt;root&gt;">&lt;root&gt;</span> <span class="type">*diffator_test.SideBySideConfig</span></summary>`,
+				`<span class="path" title="Port">Port</span> <span class="type">int</span> <del>80</del> <ins>8080</ins>`,
+				`<span class="path" title="Tags[2]">[2]</span> <span class="type">string</span> <ins>&#34;&lt;c&gt;&#34;</ins> <span class="kind">inserted</span>`,
+				`<summary><span class="path" title="Owner">Owner</span> <span class="type">*diffator_test.PatchOwner</span></summary>`,
+				`<span class="path" title="Owner.Email">Email</span> <span class="type">string</span> <pre><del>a</del><ins>b</ins>@x</pre>`,
+				`<span class="path" title="Password">Password</span> <span class="type">string</span> <del>&lt;redacted&gt;</del> <ins>&lt;redacted&gt;</ins>`,
+			},
+			excludes: []string{"secret", "hunter2", "Internal"},
+		},
+		{
+			name:     "Not collapsible",
+			htmlOpts: &diffator.HTMLOpts{Collapsible: diffator.Bool(false)},
+			contains: []string{
+				`<div><span class="path" title="Owner">Owner</span> <span class="type">*diffator_test.PatchOwner</span></div>`,
+			},
+			excludes: []string{"<details", "<summary"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := diffator.CompareObjectsHTML(c1, c2, nil, tt.htmlOpts)
+			assertSelfContained(t, doc)
+			for _, s := range tt.contains {
+				assert.Contains(t, doc, s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, doc, s)
+			}
+		})
+	}
+}
+
+func TestCompareObjectsHTMLRedactedWhole(t *testing.T) {
+	a1 := RedactedAccount{Users: []RedactedOwner{{Name: "a", Password: "hunter1"}}}
+	a2 := RedactedAccount{
+		Owner: &RedactedOwner{Name: "o", Password: "hunter2"},
+		Users: []RedactedOwner{{Name: "a", Password: "hunter1"}, {Name: "b", Password: "hunter3"}},
+	}
+	doc := diffator.CompareObjectsHTML(a1, a2, nil, nil)
+	assert.Contains(t, doc, `<ins>*diffator_test.RedactedOwner{Name:&#34;o&#34;,Password:&lt;redacted&gt;,}</ins>`)
+	assert.Contains(t, doc, `<ins>diffator_test.RedactedOwner{Name:&#34;b&#34;,Password:&lt;redacted&gt;,}</ins> <span class="kind">inserted</span>`)
+	assert.NotContains(t, doc, "hunter")
+
+	doc = diffator.CompareObjectsHTML(a2, a1, nil, nil)
+	assert.Contains(t, doc, `<del>diffator_test.RedactedOwner{Name:&#34;b&#34;,Password:&lt;redacted&gt;,}</del> <span class="kind">deleted</span>`)
+	assert.NotContains(t, doc, "hunter")
+}
+
+func TestHTMLReport(t *testing.T) {
+	r := diffator.NewHTMLReport(&diffator.HTMLOpts{
+		Title:     diffator.String("Run <1>"),
+		OmitEqual: diffator.Bool(true),
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r.AddStrings(fmt.Sprintf("strings %d", i), "a", "a"+strings.Repeat("b", i%2), nil)
+		}(i)
+	}
+	wg.Wait()
+	assert.True(t, r.AddObjects("objects", []int{1}, []int{1}, nil))
+	assert.False(t, r.AddObjects("objects & more", []int{1}, []int{2}, nil))
+	assert.Equal(t, 6, r.Differences())
+
+	doc := r.String()
+	assertSelfContained(t, doc)
+	assert.Contains(t, doc, "<title>Run &lt;1&gt;</title>")
+	assert.Contains(t, doc, `<p class="summary">12 comparisons, <span class="differ">6 with differences</span></p>`)
+	assert.Contains(t, doc, `<li><a href="#c12">objects &amp; more</a></li>`)
+	assert.Contains(t, doc, `<section id="c12" class="differ">`)
+	assert.Equal(t, 6, strings.Count(doc, "<section"))
+	assert.Equal(t, 6, strings.Count(doc, "<li><a href="))
+	assert.NotContains(t, doc, `class="equal"`)
+
+	name := filepath.Join(t.TempDir(), "report.html")
+	require.NoError(t, r.WriteFile(name))
+	b, err := os.ReadFile(name)
+	require.NoError(t, err)
+	assert.Equal(t, doc, string(b))
+}
+
+// assertSelfContained asserts that doc is an HTML document that loads nothing
+// from elsewhere, so that it opens offline.
+func assertSelfContained(t *testing.T, doc string) {
+	t.Helper()
+	assert.True(t, strings.HasPrefix(doc, "<!DOCTYPE html>\n"))
+	assert.True(t, strings.HasSuffix(doc, "</html>\n"))
+	assert.Contains(t, doc, "<style>")
+	for _, s := range []string{"http:", "https:", "<script", "<link", " src=", "@import", "url("} {
+		assert.NotContains(t, doc, s)
+	}
+}
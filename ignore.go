@@ -0,0 +1,189 @@
+package diffator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// IgnoreRule reports whether the value found at path should be left out of a
+// comparison. rv is the want value, or the got value if there is no want value.
+//
+// Any func(path Path, rv reflect.Value) bool can be used as an IgnoreRule, and
+// IgnorePath(), IgnoreField() and IgnoreType() return commonly needed rules.
+type IgnoreRule func(path Path, rv reflect.Value) bool
+
+// IgnorePath returns a rule that ignores values whose path matches expr, such as
+// `Users[*].CreatedAt` or `Meta.*`.
+//
+// Steps in expr are written as in Path.String(): `.Name` for a struct field,
+// `[2]` for a slice or array element, `[id=42]` for an element matched by a
+// SliceKey and `["key"]` for a map entry. Keys are quoted as Go strings, so they
+// may contain `]`, e.g. `Labels["a]b"]`. A `*` in place of a field name, index
+// or key matches any one step. IgnorePath panics if expr is malformed.
+func IgnorePath(expr string) IgnoreRule {
+	pattern := parsePathPattern(expr)
+	return func(path Path, _ reflect.Value) bool {
+		return pattern.matches(path)
+	}
+}
+
+// IgnoreField returns a rule that ignores struct fields with any of the given
+// names, wherever they are found.
+func IgnoreField(names ...string) IgnoreRule {
+	return func(path Path, _ reflect.Value) (ignore bool) {
+		step := path.Last()
+		if len(path) == 0 || step.Kind != FieldStep {
+			goto end
+		}
+		for _, name := range names {
+			if step.Name == name {
+				ignore = true
+				break
+			}
+		}
+	end:
+		return ignore
+	}
+}
+
+// IgnoreType returns a rule that ignores values of any of the types of the
+// values passed, e.g. IgnoreType(time.Time{}). A reflect.Type may be passed to
+// name a type directly, such as an interface type.
+func IgnoreType(values ...any) IgnoreRule {
+	types := make([]reflect.Type, len(values))
+	for i, v := range values {
+		rt, ok := v.(reflect.Type)
+		if !ok {
+			rt = reflect.TypeOf(v)
+		}
+		types[i] = rt
+	}
+	return func(_ Path, rv reflect.Value) (ignore bool) {
+		if !rv.IsValid() {
+			goto end
+		}
+		for _, rt := range types {
+			if rv.Type() == rt {
+				ignore = true
+				break
+			}
+		}
+	end:
+		return ignore
+	}
+}
+
+// pathPattern is a parsed IgnorePath() expression.
+type pathPattern []stepPattern
+
+// stepPattern matches one step of a Path. An empty text matches any step.
+type stepPattern struct {
+	kind PathStepKind
+	text string
+}
+
+func parsePathPattern(expr string) (pattern pathPattern) {
+	var end int
+	var text string
+
+	s := expr
+	for s != "" {
+		switch s[0] {
+		case '[':
+			end = bracketEnd(s, expr)
+			if end < 0 {
+				panicf("Missing ']' in path expression '%s'", expr)
+			}
+			text = s[1:end]
+			s = s[end+1:]
+			if text == "" {
+				panicf("Empty '[]' in path expression '%s'", expr)
+			}
+			pattern = append(pattern, newStepPattern(IndexStep, text))
+			continue
+		case '.':
+			s = s[1:]
+		}
+		end = strings.IndexAny(s, ".[")
+		if end < 0 {
+			end = len(s)
+		}
+		text = s[:end]
+		s = s[end:]
+		if text == "" {
+			panicf("Empty field name in path expression '%s'", expr)
+		}
+		pattern = append(pattern, newStepPattern(FieldStep, text))
+	}
+	return pattern
+}
+
+// bracketEnd returns the index of the ']' that closes the '[' starting s, or -1
+// if there is none, skipping over any quoted strings, whose quotes and escapes
+// are as in Go.
+func bracketEnd(s, expr string) (end int) {
+	end = -1
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case ']':
+			end = i
+			goto end
+		case '"', '`':
+			quoted, err := strconv.QuotedPrefix(s[i:])
+			if err != nil {
+				panicf("Unterminated quote in path expression '%s'", expr)
+			}
+			i += len(quoted) - 1
+		}
+	}
+end:
+	return end
+}
+
+func newStepPattern(kind PathStepKind, text string) stepPattern {
+	if text == "*" {
+		text = ""
+	}
+	return stepPattern{kind: kind, text: text}
+}
+
+func (p pathPattern) matches(path Path) (match bool) {
+	if len(p) != len(path) {
+		goto end
+	}
+	for i, step := range path {
+		if !p[i].matches(step) {
+			goto end
+		}
+	}
+	match = true
+end:
+	return match
+}
+
+func (p stepPattern) matches(step PathStep) (match bool) {
+	if p.text == "" {
+		match = true
+		goto end
+	}
+	switch step.Kind {
+	case FieldStep:
+		match = p.kind == FieldStep && p.text == step.Name
+	case IndexStep:
+		match = p.kind == IndexStep && p.text == strconv.Itoa(step.Index)
+	case MapKeyStep:
+		if p.kind != IndexStep {
+			goto end
+		}
+		match = p.text == keyString(step.Key) || p.text == fmt.Sprint(step.Key)
+	case ElementKeyStep:
+		if p.kind != IndexStep {
+			goto end
+		}
+		match = p.text == step.Name+"="+keyString(step.Key) || p.text == step.Name+"="+fmt.Sprint(step.Key)
+	}
+end:
+	return match
+}
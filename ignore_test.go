@@ -0,0 +1,130 @@
+package diffator_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type AuditedUser struct {
+	ID        int
+	Name      string
+	CreatedAt time.Time
+	UpdatedBy string
+}
+
+type Account struct {
+	Users []*AuditedUser
+	Meta  map[string]string
+	Notes string
+}
+
+func TestCompareObjectsIgnore(t *testing.T) {
+	t1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	want := &Account{
+		Users: []*AuditedUser{
+			{ID: 1, Name: "Alice", CreatedAt: t1, UpdatedBy: "bob"},
+			{ID: 2, Name: "Carol", CreatedAt: t1, UpdatedBy: "bob"},
+		},
+		Meta:  map[string]string{"owner": "alice", "region": "us"},
+		Notes: "first",
+	}
+	got := &Account{
+		Users: []*AuditedUser{
+			{ID: 1, Name: "Alice", CreatedAt: t2, UpdatedBy: "dave"},
+			{ID: 3, Name: "Carol", CreatedAt: t2, UpdatedBy: "dave"},
+		},
+		Meta:  map[string]string{"owner": "bob", "zone": "a"},
+		Notes: "first",
+	}
+	diff := diffator.CompareObjects(want, got, nil)
+	assert.Contains(t, diff, "UpdatedBy:(bob!=dave)")
+	assert.Contains(t, diff, "Meta:map[string]string{")
+
+	tests := []struct {
+		name     string
+		ignore   []diffator.IgnoreRule
+		wantDiff string
+	}{
+		{
+			name: "Path with wildcard index and field",
+			ignore: []diffator.IgnoreRule{
+				diffator.IgnorePath("Users[*].CreatedAt"),
+				diffator.IgnorePath("Users[*].UpdatedBy"),
+				diffator.IgnorePath("Meta.*"),
+			},
+			wantDiff: "*diffator_test.Account{Users:[]*diffator_test.AuditedUser{[1]*diffator_test.AuditedUser{ID:(2!=3),},},}",
+		},
+		{
+			name: "Path with specific index and key",
+			ignore: []diffator.IgnoreRule{
+				diffator.IgnoreField("CreatedAt", "UpdatedBy"),
+				diffator.IgnorePath("Users[1]"),
+				diffator.IgnorePath(`Meta["region"]`),
+				diffator.IgnorePath(`Meta[zone]`),
+			},
+			wantDiff: "*diffator_test.Account{Meta:map[string]string{owner:(alice!=bob),},}",
+		},
+		{
+			name: "Field name and type",
+			ignore: []diffator.IgnoreRule{
+				diffator.IgnoreType(time.Time{}),
+				diffator.IgnoreField("UpdatedBy", "Meta"),
+			},
+			wantDiff: "*diffator_test.Account{Users:[]*diffator_test.AuditedUser{[1]*diffator_test.AuditedUser{ID:(2!=3),},},}",
+		},
+		{
+			name: "Predicate",
+			ignore: []diffator.IgnoreRule{
+				diffator.IgnoreType(time.Time{}),
+				func(path diffator.Path, rv reflect.Value) bool {
+					return strings.HasPrefix(path.String(), "Meta") ||
+						rv.Kind() == reflect.String && rv.String() == "bob"
+				},
+			},
+			wantDiff: "*diffator_test.Account{Users:[]*diffator_test.AuditedUser{[1]*diffator_test.AuditedUser{ID:(2!=3),},},}",
+		},
+		{
+			name: "Everything",
+			ignore: []diffator.IgnoreRule{
+				diffator.IgnorePath("Users"),
+				diffator.IgnorePath("Meta"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffator.CompareObjects(want, got, &diffator.ObjectOpts{
+				Ignore: tt.ignore,
+			})
+			assert.Equal(t, tt.wantDiff, diff)
+		})
+	}
+}
+
+func TestIgnorePathQuotedKey(t *testing.T) {
+	want := &Account{Meta: map[string]string{"a]b": "1", `x"]y`: "1", "c": "1"}}
+	got := &Account{Meta: map[string]string{"a]b": "2", `x"]y`: "2", "c": "2"}}
+	diff := diffator.CompareObjects(want, got, &diffator.ObjectOpts{
+		Ignore: []diffator.IgnoreRule{
+			diffator.IgnorePath(`Meta["a]b"]`),
+			diffator.IgnorePath(`Meta["x\"]y"]`),
+		},
+	})
+	assert.Equal(t, "*diffator_test.Account{Meta:map[string]string{c:(1!=2),},}", diff)
+}
+
+func TestIgnorePathMalformed(t *testing.T) {
+	for _, expr := range []string{"Users[*", "Users[]", "Users..Name", "Users.", `Meta["a]`} {
+		t.Run(expr, func(t *testing.T) {
+			assert.Panics(t, func() {
+				diffator.IgnorePath(expr)
+			})
+		})
+	}
+}
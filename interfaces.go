@@ -3,6 +3,7 @@ package diffator
 type fixer interface {
 	Fixer()
 	String() string
+	segments() []segment
 }
 
 type Comparator interface {
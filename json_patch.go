@@ -0,0 +1,406 @@
+package diffator
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The operations of a JSON Patch produced by NewJSONPatch().
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+)
+
+// PatchOp is one operation of an RFC 6902 JSON Patch. Path is a JSON Pointer,
+// per RFC 6901, and Value is the value added or replaced, which is left out of
+// the JSON for a remove.
+type PatchOp struct {
+	Op    string
+	Path  string
+	Value any
+}
+
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	if op.Op == PatchRemove {
+		return marshalJSON(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{op.Op, op.Path})
+	}
+	return marshalJSON(struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}{op.Op, op.Path, op.Value})
+}
+
+// marshalJSON is json.Marshal without escaping `<`, `>` and `&` for HTML, as
+// patches are rarely embedded in HTML but values such as Redacted often have
+// them.
+func marshalJSON(v any) (b []byte, err error) {
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	err = enc.Encode(v)
+	if err != nil {
+		goto end
+	}
+	b = bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+end:
+	return b, err
+}
+
+// JSONPatch is an RFC 6902 JSON Patch document, which marshals to a JSON array
+// of operations.
+type JSONPatch []PatchOp
+
+// NewJSONPatch returns the JSON Patch that turns the JSON encoding of the want
+// value of d into that of the got value.
+//
+// Struct fields are named in paths by their `json` tags when present, or else
+// by their Go names, and fields tagged `json:"-"` or unexported are left out.
+// A field tagged `omitempty` that is empty on one side is added or removed
+// whole. Values of fields tagged `diff:"redact"` are replaced by Redacted.
+//
+// Values the comparison found equal are still replaced where one encodes as
+// null and the other does not, e.g. a nil and an empty slice. If d is nil
+// there are no values to check, so use CompareObjectsJSONPatch() for values
+// that may be equal. Elements of slices compared unordered or by key that are
+// equal are matched in order, so their patch may differ in order from got.
+func NewJSONPatch(d *Diff) JSONPatch {
+	patch := JSONPatch{}
+	if d != nil {
+		patch = appendPatchOps(patch, d, "", false)
+	}
+	return patch
+}
+
+// CompareObjectsJSONPatch compares v1 (want) with v2 (got) and returns the JSON
+// Patch that turns v1 into v2, marshaled to JSON. It returns `[]` if their JSON
+// encodings are equal.
+func CompareObjectsJSONPatch(v1, v2 any, opts *ObjectOpts) ([]byte, error) {
+	c := NewObjectComparator(v1, v2, opts)
+	d := c.Result()
+	if d == nil {
+		return marshalJSON(appendNullOps(JSONPatch{}, toReflectValue(v1), toReflectValue(v2), "", false, false))
+	}
+	return marshalJSON(NewJSONPatch(d))
+}
+
+// appendPatchOps appends the operations for d, found at JSON Pointer ptr, to
+// patch. omitEmpty is true if d is a struct field tagged `omitempty`.
+func appendPatchOps(patch JSONPatch, d *Diff, ptr string, omitEmpty bool) JSONPatch {
+	switch {
+	case d.Kind == MissingExpectedDiff:
+		patch = append(patch, PatchOp{Op: PatchRemove, Path: ptr})
+	case d.Kind == MissingActualDiff:
+		patch = append(patch, PatchOp{Op: PatchAdd, Path: ptr, Value: patchValue(d.Got, false)})
+	case omitEmpty && isEmptyJSON(d.Want):
+		patch = append(patch, PatchOp{Op: PatchAdd, Path: ptr, Value: patchValue(d.Got, d.Redacted)})
+	case omitEmpty && isEmptyJSON(d.Got):
+		patch = append(patch, PatchOp{Op: PatchRemove, Path: ptr})
+	case d.IsLeaf(), d.Redacted, isNullJSON(d.Want), isNullJSON(d.Got):
+		patch = append(patch, PatchOp{Op: PatchReplace, Path: ptr, Value: patchValue(d.Got, d.Redacted)})
+	case d.Want.Kind() == reflect.Slice, d.Want.Kind() == reflect.Array:
+		patch = appendElementOps(patch, d, ptr)
+	default:
+		for _, child := range d.Children {
+			childPtr, omit, ok := childPointer(d, child, ptr)
+			if !ok {
+				continue
+			}
+			patch = appendPatchOps(patch, child, childPtr, omit)
+		}
+		patch = appendEqualOps(patch, d, ptr)
+	}
+	return patch
+}
+
+// appendEqualOps appends the operations for the fields or entries of struct or
+// map d, found at ptr, that the comparison found equal; see appendNullOps().
+func appendEqualOps(patch JSONPatch, d *Diff, ptr string) JSONPatch {
+	fields := make(map[int]bool, len(d.Children))
+	keys := make(map[string]bool, len(d.Children))
+	for _, child := range d.Children {
+		if len(child.Path) <= len(d.Path) {
+			continue
+		}
+		step := child.Path.Last()
+		switch step.Kind {
+		case FieldStep:
+			fields[step.Index] = true
+		case MapKeyStep:
+			keys[keyString(step.Key)] = true
+		}
+	}
+	rv1, rv2 := d.Want, d.Got
+	switch rv1.Kind() {
+	case reflect.Struct:
+		tags := structTags(rv1.Type())
+		for i := 0; i < rv1.NumField(); i++ {
+			if fields[i] {
+				continue
+			}
+			name, omit, ok := jsonFieldName(rv1.Type().Field(i))
+			if !ok {
+				continue
+			}
+			fldPtr := ptr
+			if name != "" {
+				fldPtr += "/" + escapePointerToken(name)
+			}
+			patch = appendNullOps(patch, rv1.Field(i), rv2.Field(i), fldPtr, omit, tags[i].redact)
+		}
+	case reflect.Map:
+		for _, key := range SortedMapKeys(&rv1) {
+			if keys[keyString(key)] {
+				continue
+			}
+			val2 := rv2.MapIndex(key)
+			if !val2.IsValid() {
+				continue
+			}
+			keyPtr := ptr + "/" + escapePointerToken(jsonMapKey(key))
+			patch = appendNullOps(patch, rv1.MapIndex(key), val2, keyPtr, false, false)
+		}
+	}
+	return patch
+}
+
+// appendNullOps appends the operations for rv1 and rv2, found at ptr, which the
+// comparison found equal but which may still differ in their JSON encodings as
+// nil and empty slices and maps do: one is null and the other is not. omitEmpty
+// is true if they are a struct field tagged `omitempty`, and redacted true if
+// their values are to be replaced by Redacted.
+func appendNullOps(patch JSONPatch, rv1, rv2 reflect.Value, ptr string, omitEmpty, redacted bool) JSONPatch {
+	switch {
+	case omitEmpty && isEmptyJSON(rv1) && isEmptyJSON(rv2):
+	case isNullJSON(rv1) != isNullJSON(rv2):
+		patch = append(patch, PatchOp{Op: PatchReplace, Path: ptr, Value: patchValue(rv2, redacted)})
+	case isNullJSON(rv1), rv1.Kind() != rv2.Kind(), marshalsItself(rv1):
+	case rv1.Kind() == reflect.Pointer, rv1.Kind() == reflect.Interface:
+		patch = appendNullOps(patch, rv1.Elem(), rv2.Elem(), ptr, false, redacted)
+	case rv1.Kind() == reflect.Slice, rv1.Kind() == reflect.Array:
+		for i := 0; i < min(rv1.Len(), rv2.Len()); i++ {
+			patch = appendNullOps(patch, rv1.Index(i), rv2.Index(i), ptr+"/"+strconv.Itoa(i), false, redacted)
+		}
+	case rv1.Kind() == reflect.Struct, rv1.Kind() == reflect.Map:
+		patch = appendEqualOps(patch, &Diff{Want: rv1, Got: rv2}, ptr)
+	}
+	return patch
+}
+
+// marshalsItself returns true if rv has its own JSON encoding, as a
+// json.Marshaler or encoding.TextMarshaler.
+func marshalsItself(rv reflect.Value) (ok bool) {
+	rt := rv.Type()
+	for _, it := range []reflect.Type{jsonMarshalerType, textMarshalerType} {
+		if rt.Implements(it) || reflect.PointerTo(rt).Implements(it) {
+			ok = true
+			break
+		}
+	}
+	return ok
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// appendElementOps appends the operations for the elements of slice or array d
+// to patch, ordered so that each index is correct when the operations are
+// applied in turn: changed elements at their want index, then the elements
+// removed from the last to the first, then those added at their got index.
+func appendElementOps(patch JSONPatch, d *Diff, ptr string) JSONPatch {
+	var removed, added []*Diff
+
+	wantDiffers := make(map[int]bool, len(d.Children))
+	gotDiffers := make(map[int]bool, len(d.Children))
+	for _, child := range d.Children {
+		switch child.Kind {
+		case MissingExpectedDiff:
+			removed = append(removed, child)
+			wantDiffers[child.Path.Last().Index] = true
+		case MissingActualDiff:
+			added = append(added, child)
+			gotDiffers[child.Path.Last().Index] = true
+		default:
+			patch = appendPatchOps(patch, child, elementPointer(ptr, child), false)
+			wantDiffers[child.Path.Last().Index] = true
+			gotDiffers[child.gotIndex] = true
+		}
+	}
+	// Pair the elements found equal in order, as they are aligned
+	for i, j := 0, 0; i < d.Want.Len() && j < d.Got.Len(); i, j = i+1, j+1 {
+		for i < d.Want.Len() && wantDiffers[i] {
+			i++
+		}
+		for j < d.Got.Len() && gotDiffers[j] {
+			j++
+		}
+		if i == d.Want.Len() || j == d.Got.Len() {
+			break
+		}
+		patch = appendNullOps(patch, d.Want.Index(i), d.Got.Index(j), ptr+"/"+strconv.Itoa(i), false, d.Redacted)
+	}
+	sort.SliceStable(removed, func(i, j int) bool {
+		return removed[i].Path.Last().Index > removed[j].Path.Last().Index
+	})
+	for _, child := range removed {
+		patch = appendPatchOps(patch, child, elementPointer(ptr, child), false)
+	}
+	sort.SliceStable(added, func(i, j int) bool {
+		return added[i].Path.Last().Index < added[j].Path.Last().Index
+	})
+	for _, child := range added {
+		patch = appendPatchOps(patch, child, elementPointer(ptr, child), false)
+	}
+	return patch
+}
+
+func elementPointer(ptr string, child *Diff) string {
+	return ptr + "/" + strconv.Itoa(child.Path.Last().Index)
+}
+
+// childPointer returns the JSON Pointer of child, a child of d found at ptr,
+// and whether it is a field tagged `omitempty`. ok is false if child is not
+// part of the JSON encoding.
+func childPointer(d, child *Diff, ptr string) (childPtr string, omitEmpty, ok bool) {
+	var name string
+	var step PathStep
+
+	childPtr = ptr
+	if len(child.Path) == len(d.Path) {
+		// The child is the value a pointer or interface refers to
+		ok = true
+		goto end
+	}
+	step = child.Path.Last()
+	switch step.Kind {
+	case FieldStep:
+		name, omitEmpty, ok = jsonFieldName(d.Want.Type().Field(step.Index))
+		if ok && name != "" {
+			childPtr += "/" + escapePointerToken(name)
+		}
+	case MapKeyStep:
+		childPtr += "/" + escapePointerToken(jsonMapKey(step.Key))
+		ok = true
+	default:
+		childPtr += "/" + strconv.Itoa(step.Index)
+		ok = true
+	}
+end:
+	return childPtr, omitEmpty, ok
+}
+
+// jsonFieldName returns the name of fld in its JSON encoding per its `json`
+// tag, or "" for an embedded struct whose fields are promoted, and whether it is
+// tagged `omitempty`. ok is false if fld is left out of the JSON encoding.
+func jsonFieldName(fld reflect.StructField) (name string, omitEmpty, ok bool) {
+	var opts string
+
+	tag := fld.Tag.Get("json")
+	if tag == "-" {
+		goto end
+	}
+	name, opts, _ = strings.Cut(tag, ",")
+	omitEmpty = strings.Contains(","+opts+",", ",omitempty,")
+	switch {
+	case fld.Anonymous && name == "" && indirectType(fld.Type).Kind() == reflect.Struct:
+		// The fields of an embedded struct are promoted, even if it is unexported
+		ok = true
+	case !fld.IsExported():
+	case name == "":
+		name = fld.Name
+		ok = true
+	default:
+		ok = true
+	}
+end:
+	return name, omitEmpty, ok
+}
+
+// indirectType returns the type rt points to, or rt if it is not a pointer.
+func indirectType(rt reflect.Type) reflect.Type {
+	if rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	return rt
+}
+
+// jsonMapKey returns the string that encoding/json uses for map key key.
+func jsonMapKey(key reflect.Value) (s string) {
+	if key.Kind() == reflect.String {
+		s = key.String()
+		goto end
+	}
+	if key.CanInterface() {
+		if tm, ok := key.Interface().(encoding.TextMarshaler); ok {
+			b, err := tm.MarshalText()
+			if err == nil {
+				s = string(b)
+				goto end
+			}
+		}
+	}
+	s = fmt.Sprint(key)
+end:
+	return s
+}
+
+// escapePointerToken escapes `~` and `/` in a JSON Pointer reference token per
+// RFC 6901.
+func escapePointerToken(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
+
+// patchValue returns the value of rv to marshal into a JSON Patch, or Redacted
+// if redacted is true.
+func patchValue(rv reflect.Value, redacted bool) (v any) {
+	switch {
+	case redacted:
+		v = Redacted
+	case !rv.IsValid():
+		v = nil
+	default:
+		v, _ = interfaceOf(&rv)
+	}
+	return v
+}
+
+// isNullJSON returns true if rv encodes as JSON null.
+func isNullJSON(rv reflect.Value) (null bool) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		null = true
+	case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+		null = rv.IsNil()
+	}
+	return null
+}
+
+// isEmptyJSON returns true if rv is empty as defined for `omitempty` by
+// encoding/json.
+func isEmptyJSON(rv reflect.Value) (empty bool) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		empty = true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		empty = rv.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Pointer:
+		empty = rv.IsZero()
+	}
+	return empty
+}
@@ -0,0 +1,294 @@
+package diffator_test
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type PatchConfig struct {
+	Name     string            `json:"name"`
+	Replicas int               `json:"replicas,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	Ports    []int             `json:"ports"`
+	Aliases  []string          `json:"aliases,omitempty"`
+	Secret   string            `json:"-"`
+	Owner    *PatchOwner       `json:"owner,omitempty"`
+	Token    string            `json:"token" diff:"redact"`
+	Note     string
+	internal int
+	PatchEmbedded
+}
+
+type PatchOwner struct {
+	Email string `json:"email"`
+}
+
+type PatchEmbedded struct {
+	Zone string `json:"zone"`
+}
+
+func TestCompareObjectsJSONPatch(t *testing.T) {
+	base := func() PatchConfig {
+		return PatchConfig{
+			Name:   "web",
+			Labels: map[string]string{"app": "web", "a/b": "x"},
+			Ports:  []int{80, 443},
+			Owner:  &PatchOwner{Email: "a@x"},
+		}
+	}
+	tests := []struct {
+		name      string
+		v1        any
+		v2        any
+		opts      *diffator.ObjectOpts
+		wantPatch string
+	}{
+		{
+			name:      "Equal",
+			v1:        base(),
+			v2:        base(),
+			wantPatch: `[]`,
+		},
+		{
+			name: "Fields by JSON name",
+			v1:   base(),
+			v2: func() PatchConfig {
+				c := base()
+				c.Name = "api"
+				c.Note = "new"
+				c.Secret = "ignored"
+				c.internal = 1
+				c.Owner.Email = "b@x"
+				c.Zone = "eu"
+				return c
+			}(),
+			wantPatch: `[{"op":"replace","path":"/name","value":"api"},` +
+				`{"op":"replace","path":"/owner/email","value":"b@x"},` +
+				`{"op":"replace","path":"/Note","value":"new"},` +
+				`{"op":"replace","path":"/zone","value":"eu"}]`,
+		},
+		{
+			name: "Omit empty",
+			v1:   base(),
+			v2: func() PatchConfig {
+				c := base()
+				c.Replicas = 3
+				c.Owner = nil
+				return c
+			}(),
+			wantPatch: `[{"op":"add","path":"/replicas","value":3},` +
+				`{"op":"remove","path":"/owner"}]`,
+		},
+		{
+			name: "Map keys",
+			v1:   base(),
+			v2: func() PatchConfig {
+				c := base()
+				c.Labels = map[string]string{"a/b": "y", "tier": "front"}
+				return c
+			}(),
+			wantPatch: `[{"op":"replace","path":"/labels/a~1b","value":"y"},` +
+				`{"op":"remove","path":"/labels/app"},` +
+				`{"op":"add","path":"/labels/tier","value":"front"}]`,
+		},
+		{
+			name: "Slice indices",
+			v1:   []string{"a", "b", "c", "d", "e"},
+			v2:   []string{"x", "a", "B", "c", "e", "f"},
+			wantPatch: `[{"op":"replace","path":"/1","value":"B"},` +
+				`{"op":"remove","path":"/3"},` +
+				`{"op":"add","path":"/0","value":"x"},` +
+				`{"op":"add","path":"/5","value":"f"}]`,
+		},
+		{
+			name: "Null slice replaced whole",
+			v1:   base(),
+			v2: func() PatchConfig {
+				c := base()
+				c.Ports = nil
+				return c
+			}(),
+			wantPatch: `[{"op":"replace","path":"/ports","value":null}]`,
+		},
+		{
+			name: "Nil and empty replaced",
+			v1: func() PatchConfig {
+				c := base()
+				c.Labels, c.Ports = nil, nil
+				return c
+			}(),
+			v2: func() PatchConfig {
+				c := base()
+				c.Name = "api"
+				c.Labels, c.Ports = map[string]string{}, []int{}
+				return c
+			}(),
+			wantPatch: `[{"op":"replace","path":"/name","value":"api"},` +
+				`{"op":"replace","path":"/labels","value":{}},` +
+				`{"op":"replace","path":"/ports","value":[]}]`,
+		},
+		{
+			name:      "Nil and empty equal",
+			v1:        []PatchConfig{{Aliases: []string{}}},
+			v2:        []PatchConfig{{Labels: map[string]string{}}},
+			wantPatch: `[{"op":"replace","path":"/0/labels","value":{}}]`,
+		},
+		{
+			name: "Redacted",
+			v1:   base(),
+			v2: func() PatchConfig {
+				c := base()
+				c.Token = "s3cret"
+				return c
+			}(),
+			wantPatch: `[{"op":"replace","path":"/token","value":"<redacted>"}]`,
+		},
+		{
+			name: "Keyed slice",
+			v1:   []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}, {ID: 3, Email: "c@x"}},
+			v2:   []KeyedUser{{ID: 3, Email: "C@x"}, {ID: 1, Email: "a@x"}, {ID: 4, Email: "d@x"}},
+			opts: &diffator.ObjectOpts{
+				SliceKeys: []diffator.SliceKey{diffator.KeyField[KeyedUser]("ID")},
+			},
+			wantPatch: `[{"op":"replace","path":"/2/Email","value":"C@x"},` +
+				`{"op":"remove","path":"/1"},` +
+				`{"op":"add","path":"/2","value":{"ID":4,"Email":"d@x"}}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := diffator.CompareObjectsJSONPatch(tt.v1, tt.v2, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPatch, string(patch))
+		})
+	}
+}
+
+func TestJSONPatchApplies(t *testing.T) {
+	tests := []struct {
+		name string
+		v1   any
+		v2   any
+		opts *diffator.ObjectOpts
+	}{
+		{
+			name: "Aligned slice",
+			v1:   []string{"a", "b", "c", "d", "e", "g"},
+			v2:   []string{"x", "a", "B", "c", "e", "f", "g", "h"},
+		},
+		{
+			name: "Unordered slice",
+			v1:   []int{1, 2, 3, 4},
+			v2:   []int{5, 4, 6, 3, 2},
+			opts: &diffator.ObjectOpts{UnorderedSlices: true},
+		},
+		{
+			name: "Index mode",
+			v1:   []int{1, 2, 3, 4},
+			v2:   []int{0, 2},
+			opts: &diffator.ObjectOpts{SliceIndexMode: true},
+		},
+		{
+			name: "Nil and empty root",
+			v1:   []int(nil),
+			v2:   []int{},
+		},
+		{
+			name: "Nil and empty elements",
+			v1:   [][]int{nil, {1}, {}, {2}},
+			v2:   [][]int{{0}, {}, {1}, nil},
+		},
+		{
+			name: "Nil and empty map values",
+			v1:   map[string]*PatchConfig{"a": {}, "b": {Name: "b"}},
+			v2:   map[string]*PatchConfig{"a": {Ports: []int{}}, "b": {Name: "B", Labels: map[string]string{}}},
+		},
+		{
+			name: "Nested",
+			v1: map[string][]PatchOwner{
+				"x": {{Email: "a"}, {Email: "b"}},
+				"y": {{Email: "c"}},
+			},
+			v2: map[string][]PatchOwner{
+				"x": {{Email: "b"}, {Email: "B"}},
+				"z": {},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := diffator.CompareObjectsJSONPatch(tt.v1, tt.v2, tt.opts)
+			require.NoError(t, err)
+			got := applyJSONPatch(t, toJSONValue(t, tt.v1), patch)
+			want := toJSONValue(t, tt.v2)
+			if tt.opts != nil && tt.opts.UnorderedSlices {
+				assert.ElementsMatch(t, want, got)
+				return
+			}
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func toJSONValue(t *testing.T, v any) (jv any) {
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(b, &jv))
+	return jv
+}
+
+// applyJSONPatch applies the add, remove and replace operations of patch to
+// doc, a value decoded from JSON.
+func applyJSONPatch(t *testing.T, doc any, patch []byte) any {
+	var ops []struct {
+		Op    string `json:"op"`
+		Path  string `json:"path"`
+		Value any    `json:"value"`
+	}
+	require.NoError(t, json.Unmarshal(patch, &ops))
+	for _, op := range ops {
+		doc = applyPatchOp(t, doc, strings.Split(op.Path, "/")[1:], op.Op, op.Value)
+	}
+	return doc
+}
+
+func applyPatchOp(t *testing.T, doc any, tokens []string, op string, value any) any {
+	if len(tokens) == 0 {
+		return value
+	}
+	token := strings.NewReplacer("~1", "/", "~0", "~").Replace(tokens[0])
+	switch node := doc.(type) {
+	case map[string]any:
+		switch {
+		case len(tokens) > 1:
+			node[token] = applyPatchOp(t, node[token], tokens[1:], op, value)
+		case op == "remove":
+			delete(node, token)
+		default:
+			node[token] = value
+		}
+		return node
+	case []any:
+		i, err := strconv.Atoi(token)
+		require.NoError(t, err)
+		switch {
+		case len(tokens) > 1:
+			node[i] = applyPatchOp(t, node[i], tokens[1:], op, value)
+		case op == "remove":
+			node = append(node[:i], node[i+1:]...)
+		case op == "add":
+			node = append(node[:i], append([]any{value}, node[i:]...)...)
+		default:
+			node[i] = value
+		}
+		return node
+	}
+	t.Fatalf("Cannot apply %s at '%s' to %v", op, token, doc)
+	return nil
+}
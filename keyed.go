@@ -0,0 +1,184 @@
+package diffator
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SliceKey matches the elements of slices and arrays by a key rather than by
+// their position, so they are compared like a map, reporting the elements
+// added, removed and changed at paths such as `Users[id=42].Email`. Create one
+// with KeyField() or KeyFunc() and add it to ObjectOpts.SliceKeys.
+type SliceKey struct {
+	rt   reflect.Type
+	name string
+	key  func(rv reflect.Value) (key any, ok bool)
+}
+
+// KeyField returns a SliceKey that matches elements of type T, or *T, by their
+// field named field, shown in paths by its lower-cased name, e.g.
+// KeyField[User]("ID") for `Users[id=42].Email`. KeyField panics if T has no
+// such exported field or its type is not comparable.
+func KeyField[T any](field string) SliceKey {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	if rt.Kind() != reflect.Struct {
+		panicf("KeyField type %s is not a struct", rt)
+	}
+	fld, ok := rt.FieldByName(field)
+	switch {
+	case !ok:
+		panicf("KeyField type %s has no field '%s'", rt, field)
+	case !fld.IsExported():
+		panicf("KeyField field %s.%s is not exported", rt, field)
+	case !fld.Type.Comparable():
+		panicf("KeyField field %s.%s of type %s is not comparable", rt, field, fld.Type)
+	}
+	return SliceKey{
+		rt:   rt,
+		name: strings.ToLower(field),
+		key: func(rv reflect.Value) (key any, ok bool) {
+			fv, err := rv.FieldByIndexErr(fld.Index)
+			if err != nil {
+				goto end
+			}
+			key, ok = interfaceOf(&fv)
+		end:
+			return key, ok
+		},
+	}
+}
+
+// KeyFunc returns a SliceKey that matches elements of type T, or *T, by the key
+// fn returns for them, shown in paths as name, e.g.:
+//
+//	diffator.KeyFunc("email", func(u User) string {
+//		return strings.ToLower(u.Email)
+//	})
+func KeyFunc[T any, K comparable](name string, fn func(T) K) SliceKey {
+	return SliceKey{
+		rt:   reflect.TypeOf((*T)(nil)).Elem(),
+		name: name,
+		key: func(rv reflect.Value) (key any, ok bool) {
+			var v any
+			v, ok = interfaceOf(&rv)
+			if ok {
+				key = fn(v.(T))
+			}
+			return key, ok
+		},
+	}
+}
+
+// sliceKey returns the SliceKey registered for the elements of slice or array
+// type rt, if any, and whether elements are pointers to the type it keys.
+func (o *ObjectComparator) sliceKey(rt reflect.Type) (sk SliceKey, deref bool, ok bool) {
+	et := rt.Elem()
+	sk, ok = o.opts.sliceKeys[et]
+	if ok || et.Kind() != reflect.Pointer {
+		goto end
+	}
+	sk, ok = o.opts.sliceKeys[et.Elem()]
+	deref = ok
+end:
+	return sk, deref, ok
+}
+
+// diffKeyedValues compares two slices or arrays by key if a SliceKey is
+// registered for their elements, in which case handled is true.
+func (o *ObjectComparator) diffKeyedValues(rv1, rv2 *reflect.Value, path Path) (d *Diff, handled bool) {
+	var diffs []*Diff
+
+	sk, deref, ok := o.sliceKey(rv1.Type())
+	if !ok {
+		goto end
+	}
+	diffs, handled = o.diffKeyed(rv1, rv2, path, sk, deref)
+	if handled {
+		d = newParentDiff(path, rv1, rv2, diffs...)
+	}
+end:
+	return d, handled
+}
+
+// diffKeyed compares the elements of two slices or arrays by matching those
+// with equal keys per sk. Elements with keys only found in rv1 are reported as
+// deleted, and those only found in rv2 as inserted. ok is false if any element
+// has no key, such as a nil pointer, or if keys are duplicated, in which case
+// the elements cannot be matched by key.
+func (o *ObjectComparator) diffKeyed(rv1, rv2 *reflect.Value, path Path, sk SliceKey, deref bool) (diffs []*Diff, ok bool) {
+	var keys1, keys2 []any
+	var index1, index2 map[any]int
+
+	keys1, index1, ok = elementKeys(rv1, sk, deref)
+	if !ok {
+		goto end
+	}
+	keys2, index2, ok = elementKeys(rv2, sk, deref)
+	if !ok {
+		goto end
+	}
+	for i, key := range keys1 {
+		idx1 := rv1.Index(i)
+		keyPath := path.withElementKey(sk.name, reflect.ValueOf(key), i)
+		j, found := index2[key]
+		if !found {
+			d := o.missingDiff(MissingExpectedDiff, keyPath, &idx1, nil)
+			if d != nil {
+				diffs = append(diffs, d)
+			}
+			continue
+		}
+		idx2 := rv2.Index(j)
+		d := o.diffValues(&idx1, &idx2, keyPath)
+		d.setGotIndex(j)
+		if d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+	for j, key := range keys2 {
+		if _, found := index1[key]; found {
+			continue
+		}
+		idx2 := rv2.Index(j)
+		keyPath := path.withElementKey(sk.name, reflect.ValueOf(key), j)
+		d := o.missingDiff(MissingActualDiff, keyPath, nil, &idx2)
+		if d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+end:
+	return diffs, ok
+}
+
+// elementKeys returns the keys of the elements of rv in order, and the index of
+// the element with each key, or false if any element has no key or a key is
+// duplicated.
+func elementKeys(rv *reflect.Value, sk SliceKey, deref bool) (keys []any, index map[any]int, ok bool) {
+	var key any
+
+	n := rv.Len()
+	keys = make([]any, n)
+	index = make(map[any]int, n)
+	for i := 0; i < n; i++ {
+		elem := rv.Index(i)
+		if deref {
+			if elem.IsNil() {
+				goto end
+			}
+			elem = elem.Elem()
+		}
+		key, ok = sk.key(elem)
+		if !ok {
+			goto end
+		}
+		if _, dup := index[key]; dup {
+			ok = false
+			goto end
+		}
+		keys[i] = key
+		index[key] = i
+	}
+	ok = true
+end:
+	return keys, index, ok
+}
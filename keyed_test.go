@@ -0,0 +1,116 @@
+package diffator_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type KeyedUser struct {
+	ID    int
+	Email string
+}
+
+type KeyedAccount struct {
+	Users []KeyedUser
+}
+
+func TestCompareObjectsKeyed(t *testing.T) {
+	byID := &diffator.ObjectOpts{
+		SliceKeys: []diffator.SliceKey{diffator.KeyField[KeyedUser]("ID")},
+	}
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name: "Reordered is equal",
+			v1:   []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}},
+			v2:   []KeyedUser{{ID: 2, Email: "b@x"}, {ID: 1, Email: "a@x"}},
+			opts: byID,
+		},
+		{
+			name: "Changed, removed and added",
+			v1: KeyedAccount{Users: []KeyedUser{
+				{ID: 1, Email: "a@x"},
+				{ID: 42, Email: "old@x"},
+				{ID: 3, Email: "c@x"},
+			}},
+			v2: KeyedAccount{Users: []KeyedUser{
+				{ID: 4, Email: "d@x"},
+				{ID: 42, Email: "new@x"},
+				{ID: 1, Email: "a@x"},
+			}},
+			opts: byID,
+			wantDiff: `diffator_test.KeyedAccount{Users:[]diffator_test.KeyedUser{` +
+				`[id=42]diffator_test.KeyedUser{Email:(old@x!=new@x),},` +
+				`[id=3]<deleted:diffator_test.KeyedUser{ID:3,Email:"c@x",}>,` +
+				`[id=4]<inserted:diffator_test.KeyedUser{ID:4,Email:"d@x",}>,},}`,
+		},
+		{
+			name:     "Pointer elements",
+			v1:       []*KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}},
+			v2:       []*KeyedUser{{ID: 2, Email: "B@x"}, {ID: 1, Email: "a@x"}},
+			opts:     byID,
+			wantDiff: `[]*diffator_test.KeyedUser{[id=2]*diffator_test.KeyedUser{Email:(b@x!=B@x),},}`,
+		},
+		{
+			name: "Key func",
+			v1:   []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}},
+			v2:   []KeyedUser{{ID: 3, Email: "B@X"}, {ID: 1, Email: "a@x"}},
+			opts: &diffator.ObjectOpts{
+				SliceKeys: []diffator.SliceKey{
+					diffator.KeyFunc("email", func(u KeyedUser) string {
+						return strings.ToLower(u.Email)
+					}),
+				},
+			},
+			wantDiff: `[]diffator_test.KeyedUser{[email="b@x"]diffator_test.KeyedUser{ID:(2!=3),Email:(b@x!=B@X),},}`,
+		},
+		{
+			name:     "Duplicate keys compare by position",
+			v1:       []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 1, Email: "b@x"}},
+			v2:       []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 1, Email: "c@x"}},
+			opts:     byID,
+			wantDiff: `[]diffator_test.KeyedUser{[1]diffator_test.KeyedUser{Email:(b@x!=c@x),},}`,
+		},
+		{
+			name: "Ignore by key path",
+			v1:   KeyedAccount{Users: []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}}},
+			v2:   KeyedAccount{Users: []KeyedUser{{ID: 1, Email: "A@x"}, {ID: 2, Email: "B@x"}}},
+			opts: &diffator.ObjectOpts{
+				SliceKeys: []diffator.SliceKey{diffator.KeyField[KeyedUser]("ID")},
+				Ignore:    []diffator.IgnoreRule{diffator.IgnorePath("Users[id=1].Email")},
+			},
+			wantDiff: `diffator_test.KeyedAccount{Users:[]diffator_test.KeyedUser{[id=2]diffator_test.KeyedUser{Email:(b@x!=B@x),},},}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
+
+func TestCompareObjectsKeyedPath(t *testing.T) {
+	v1 := KeyedAccount{Users: []KeyedUser{{ID: 42, Email: "old@x"}}}
+	v2 := KeyedAccount{Users: []KeyedUser{{ID: 42, Email: "new@x"}}}
+	d := diffator.CompareObjectsResult(v1, v2, &diffator.ObjectOpts{
+		SliceKeys: []diffator.SliceKey{diffator.KeyField[KeyedUser]("ID")},
+	})
+	leaves := d.Leaves()
+	if assert.Len(t, leaves, 1) {
+		assert.Equal(t, "Users[id=42].Email", leaves[0].Path.String())
+	}
+}
+
+func TestKeyFieldPanics(t *testing.T) {
+	assert.Panics(t, func() { diffator.KeyField[KeyedUser]("Name") })
+	assert.Panics(t, func() { diffator.KeyField[KeyedAccount]("Users") })
+	assert.Panics(t, func() { diffator.KeyField[int]("ID") })
+}
@@ -0,0 +1,120 @@
+package diffator_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Pipeline struct {
+	Name   string
+	Gain   complex64
+	Input  <-chan int
+	Output chan<- int
+}
+
+func TestCompareObjectsComplexAndChan(t *testing.T) {
+	ch1 := make(chan int, 2)
+	ch2 := make(chan int)
+	ch1 <- 1
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		wantDiff string
+	}{
+		{
+			name: "Equal complex",
+			v1:   complex(1, 2),
+			v2:   complex(1, 2),
+		},
+		{
+			name:     "Real part differs",
+			v1:       complex(1, 2),
+			v2:       complex(3, 2),
+			wantDiff: "complex128{real:(1!=3),}",
+		},
+		{
+			name:     "Both parts differ",
+			v1:       complex64(complex(1, 2)),
+			v2:       complex64(complex(3, 4)),
+			wantDiff: "complex64{real:(1!=3),imag:(2!=4),}",
+		},
+		{
+			name: "Same channel",
+			v1:   &Pipeline{Input: ch1, Output: ch2},
+			v2:   &Pipeline{Input: ch1, Output: ch2},
+		},
+		{
+			name: "Different channels",
+			v1:   &Pipeline{Input: ch1, Gain: complex(1, 0)},
+			v2:   &Pipeline{Input: ch2, Gain: complex(1, 0.5)},
+			wantDiff: "*diffator_test.Pipeline{Gain:complex64{imag:(0!=0.5),}," +
+				"Input:(<-chan int{len:1,cap:2}!=<-chan int{len:0,cap:0}),}",
+		},
+		{
+			name:     "Nil channel",
+			v1:       &Pipeline{Output: ch2},
+			v2:       &Pipeline{},
+			wantDiff: "*diffator_test.Pipeline{Output:(chan<- int{len:0,cap:0}!=chan<- int(nil)),}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, nil))
+		})
+	}
+}
+
+func TestReflectorComplexAndChan(t *testing.T) {
+	var nilChan chan string
+	ch := make(chan string, 3)
+	tests := []struct {
+		name       string
+		value      any
+		wantString string
+		wantAny    any
+	}{
+		{
+			name:       "complex64",
+			value:      struct{ c complex64 }{complex(1.5, -2)},
+			wantString: "struct { c complex64 }{c:(1.5-2i),}",
+			wantAny:    complex64(complex(1.5, -2)),
+		},
+		{
+			name:       "complex128",
+			value:      struct{ c complex128 }{complex(0, 1)},
+			wantString: "struct { c complex128 }{c:(0+1i),}",
+			wantAny:    complex(0, 1),
+		},
+		{
+			name:       "uint8",
+			value:      struct{ c uint8 }{7},
+			wantString: "struct { c uint8 }{c:7,}",
+			wantAny:    uint8(7),
+		},
+		{
+			name:       "nil chan",
+			value:      struct{ c chan string }{nilChan},
+			wantString: "struct { c chan string }{c:chan string(nil),}",
+			wantAny:    "chan string(nil)",
+		},
+		{
+			name:       "chan",
+			value:      struct{ c chan string }{ch},
+			wantString: "struct { c chan string }{c:chan string{len:0,cap:3},}",
+			wantAny:    "chan string{len:0,cap:3}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := diffator.NewReflector(tt.value)
+			assert.Equal(t, tt.wantString, r.String())
+			// The unexported field cannot be converted with Interface().
+			fld := reflect.ValueOf(tt.value).Field(0)
+			assert.Equal(t, tt.wantAny, r.AsAny(&fld))
+		})
+	}
+}
@@ -1,40 +1,175 @@
 package diffator
 
-// longestCommonSubstr finds the longest common substring between two strings, properly handling Unicode.
-func longestCommonSubstr(s1, s2 string) (string, int) {
-	var maxLength, endIndex int
+import (
+	"sync"
+)
 
-	runes1, runes2 := []rune(s1), []rune(s2)
-	lenR1, lenR2 := len(runes1), len(runes2)
+// longestCommonSubstr finds the longest common run of tokens between two token
+// id slices, returning its position in each and its length. When there are
+// several such runs the one ending first in t1 is returned, along with its first
+// occurrence in t2.
+//
+// It builds a suffix automaton of t2 and then walks t1 through it, so it takes
+// time and space linear in the length of the inputs.
+func longestCommonSubstr(t1, t2 []int32) (pos1, pos2, length int) {
+	var state, n, best int32
 
-	// Create a 2D slice to store lengths of longest common suffixes
-	lcsSuffix := make([][]int, lenR1+1)
-	for i := range lcsSuffix {
-		lcsSuffix[i] = make([]int, lenR2+1)
+	// If no common substring can exist
+	if len(t1) == 0 || len(t2) == 0 {
+		return 0, 0, 0
 	}
 
-	// Build lcsSuffix in bottom up manner
-	for i := 0; i <= lenR1; i++ {
-		for j := 0; j <= lenR2; j++ {
-			if i == 0 || j == 0 {
-				lcsSuffix[i][j] = 0
-			} else if runes1[i-1] == runes2[j-1] {
-				lcsSuffix[i][j] = lcsSuffix[i-1][j-1] + 1
-				if maxLength < lcsSuffix[i][j] {
-					maxLength = lcsSuffix[i][j]
-					endIndex = i
-				}
-			} else {
-				lcsSuffix[i][j] = 0
-			}
+	sa := newSuffixAutomaton(t2)
+	defer automatonPool.Put(sa)
+	for i, tok := range t1 {
+		for state != 0 && sa.edge(state, tok) == -1 {
+			state = sa.link[state]
+			n = sa.length[state]
 		}
+		e := sa.edge(state, tok)
+		if e == -1 {
+			n = 0
+			continue
+		}
+		state = sa.edgeTo[e]
+		n++
+		if n <= int32(length) {
+			continue
+		}
+		length = int(n)
+		pos1 = i + 1 - length
+		best = state
 	}
+	if length > 0 {
+		pos2 = int(sa.firstEnd[best]) + 1 - length
+	}
+	return pos1, pos2, length
+}
 
-	// If no common substring exists
-	if maxLength == 0 {
-		return "", 0
+// maxListedEdges is the number of edges a suffix automaton state holds in a
+// linked list before they are also indexed by a map.
+const maxListedEdges = 8
+
+// suffixAutomaton is the minimal automaton recognising every substring of a
+// token sequence. States are stored in parallel slices rather than as structs
+// with maps to keep memory use to a few dozen bytes per token.
+type suffixAutomaton struct {
+	length   []int32 // Length of the longest substring reaching each state
+	link     []int32 // Suffix link of each state
+	firstEnd []int32 // End position of the first occurrence of each state
+	head     []int32 // First edge of each state, or -1
+	degree   []int32 // Number of edges of each state
+	edgeTok  []int32
+	edgeTo   []int32
+	edgeNext []int32
+	index    map[int32]map[int32]int32 // Token to edge, for states with many edges
+	last     int32
+}
+
+// automatonPool recycles automatons because findExactInfixes builds one for
+// every common substring it finds.
+var automatonPool = sync.Pool{
+	New: func() any {
+		return &suffixAutomaton{
+			index: make(map[int32]map[int32]int32),
+		}
+	},
+}
+
+// newSuffixAutomaton returns an automaton for tokens from automatonPool, which
+// the caller should return to the pool when done.
+func newSuffixAutomaton(tokens []int32) *suffixAutomaton {
+	sa := automatonPool.Get().(*suffixAutomaton)
+	sa.length = sa.length[:0]
+	sa.link = sa.link[:0]
+	sa.firstEnd = sa.firstEnd[:0]
+	sa.head = sa.head[:0]
+	sa.degree = sa.degree[:0]
+	sa.edgeTok = sa.edgeTok[:0]
+	sa.edgeTo = sa.edgeTo[:0]
+	sa.edgeNext = sa.edgeNext[:0]
+	sa.last = 0
+	clear(sa.index)
+	sa.addState(0, -1, -1)
+	for i, tok := range tokens {
+		sa.extend(tok, int32(i))
 	}
+	return sa
+}
 
-	// Return the longest common substring
-	return string(runes1[endIndex-maxLength : endIndex]), endIndex - maxLength
+func (sa *suffixAutomaton) addState(length, link, firstEnd int32) int32 {
+	sa.length = append(sa.length, length)
+	sa.link = append(sa.link, link)
+	sa.firstEnd = append(sa.firstEnd, firstEnd)
+	sa.head = append(sa.head, -1)
+	sa.degree = append(sa.degree, 0)
+	return int32(len(sa.length) - 1)
+}
+
+// edge returns the edge leaving state for tok, or -1 if there is none.
+func (sa *suffixAutomaton) edge(state, tok int32) int32 {
+	if sa.degree[state] > maxListedEdges {
+		e, ok := sa.index[state][tok]
+		if !ok {
+			e = -1
+		}
+		return e
+	}
+	for e := sa.head[state]; e != -1; e = sa.edgeNext[e] {
+		if sa.edgeTok[e] == tok {
+			return e
+		}
+	}
+	return -1
+}
+
+func (sa *suffixAutomaton) addEdge(state, tok, to int32) {
+	e := int32(len(sa.edgeTok))
+	sa.edgeTok = append(sa.edgeTok, tok)
+	sa.edgeTo = append(sa.edgeTo, to)
+	sa.edgeNext = append(sa.edgeNext, sa.head[state])
+	sa.head[state] = e
+	sa.degree[state]++
+	switch {
+	case sa.degree[state] == maxListedEdges+1:
+		m := make(map[int32]int32, 2*maxListedEdges)
+		for e := sa.head[state]; e != -1; e = sa.edgeNext[e] {
+			m[sa.edgeTok[e]] = e
+		}
+		sa.index[state] = m
+	case sa.degree[state] > maxListedEdges+1:
+		sa.index[state][tok] = e
+	}
+}
+
+func (sa *suffixAutomaton) extend(tok, pos int32) {
+	cur := sa.addState(sa.length[sa.last]+1, 0, pos)
+	p := sa.last
+	for p != -1 && sa.edge(p, tok) == -1 {
+		sa.addEdge(p, tok, cur)
+		p = sa.link[p]
+	}
+	sa.last = cur
+	if p == -1 {
+		return
+	}
+	q := sa.edgeTo[sa.edge(p, tok)]
+	if sa.length[p]+1 == sa.length[q] {
+		sa.link[cur] = q
+		return
+	}
+	clone := sa.addState(sa.length[p]+1, sa.link[q], sa.firstEnd[q])
+	for e := sa.head[q]; e != -1; e = sa.edgeNext[e] {
+		sa.addEdge(clone, sa.edgeTok[e], sa.edgeTo[e])
+	}
+	for p != -1 {
+		e := sa.edge(p, tok)
+		if e == -1 || sa.edgeTo[e] != q {
+			break
+		}
+		sa.edgeTo[e] = clone
+		p = sa.link[p]
+	}
+	sa.link[q] = clone
+	sa.link[cur] = clone
 }
@@ -0,0 +1,56 @@
+package diffator
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// naiveLongestCommonSubstr is the original O(n·m) dynamic programming
+// implementation, kept as a reference for longestCommonSubstr.
+func naiveLongestCommonSubstr(t1, t2 []int32) (pos1, pos2, length int) {
+	var endIndex1, endIndex2 int
+	lcsSuffix := make([][]int, len(t1)+1)
+	for i := range lcsSuffix {
+		lcsSuffix[i] = make([]int, len(t2)+1)
+	}
+	for i := 1; i <= len(t1); i++ {
+		for j := 1; j <= len(t2); j++ {
+			if t1[i-1] != t2[j-1] {
+				continue
+			}
+			lcsSuffix[i][j] = lcsSuffix[i-1][j-1] + 1
+			if length < lcsSuffix[i][j] {
+				length = lcsSuffix[i][j]
+				endIndex1 = i
+				endIndex2 = j
+			}
+		}
+	}
+	if length == 0 {
+		return 0, 0, 0
+	}
+	return endIndex1 - length, endIndex2 - length, length
+}
+
+func TestLongestCommonSubstr(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	randTokens := func(n, alphabet int) []int32 {
+		tokens := make([]int32, n)
+		for i := range tokens {
+			tokens[i] = int32(rnd.Intn(alphabet))
+		}
+		return tokens
+	}
+	for i := 0; i < 2000; i++ {
+		alphabet := 1 + rnd.Intn(30)
+		t1 := randTokens(rnd.Intn(60), alphabet)
+		t2 := randTokens(rnd.Intn(60), alphabet)
+		pos1, pos2, length := longestCommonSubstr(t1, t2)
+		wantPos1, wantPos2, wantLength := naiveLongestCommonSubstr(t1, t2)
+		if pos1 != wantPos1 || pos2 != wantPos2 || length != wantLength {
+			t.Fatalf("longestCommonSubstr(%v, %v):\n\t got: %d,%d,%d\n\twant: %d,%d,%d",
+				t1, t2, pos1, pos2, length, wantPos1, wantPos2, wantLength,
+			)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package diffator
+
+import (
+	"strings"
+)
+
+const (
+	bothLinePrefix  = " "
+	leftLinePrefix  = "-"
+	rightLinePrefix = "+"
+	elidedLines     = "..."
+	noNewlineAtEOF  = `\ No newline at end of file`
+)
+
+// diffLine is a single line of a segment, without its trailing newline.
+type diffLine struct {
+	kind segmentKind
+	text string
+	eol  bool // true if the line was terminated by a newline
+}
+
+// styled returns the line with its prefix, styled by st.
+func (l diffLine) styled(st Styler) (s string) {
+	s = l.prefix() + l.text
+	switch l.kind {
+	case leftSegment:
+		s = st.Want(s)
+	case rightSegment:
+		s = st.Got(s)
+	default:
+		s = st.Context(s)
+	}
+	return s
+}
+
+func (l diffLine) prefix() (p string) {
+	switch l.kind {
+	case leftSegment:
+		p = leftLinePrefix
+	case rightSegment:
+		p = rightLinePrefix
+	default:
+		p = bothLinePrefix
+	}
+	return p
+}
+
+// toLines splits segments into lines, reordering each run of changed lines so
+// all the left (removed) lines come before all the right (added) lines.
+func toLines(segs []segment) (lines []diffLine) {
+	var left, right []diffLine
+	flush := func() {
+		lines = append(lines, left...)
+		lines = append(lines, right...)
+		left, right = nil, nil
+	}
+	for _, seg := range segs {
+		split := splitLines(seg)
+		switch seg.kind {
+		case leftSegment:
+			left = append(left, split...)
+		case rightSegment:
+			right = append(right, split...)
+		default:
+			flush()
+			lines = append(lines, split...)
+		}
+	}
+	flush()
+	return lines
+}
+
+func splitLines(seg segment) (lines []diffLine) {
+	for _, tok := range LineTokenizer(seg.text) {
+		eol := strings.HasSuffix(tok, "\n")
+		lines = append(lines, diffLine{
+			kind: seg.kind,
+			text: strings.TrimSuffix(tok, "\n"),
+			eol:  eol,
+		})
+	}
+	return lines
+}
+
+// contextMask returns which lines to show given ContextLines unchanged lines
+// around each changed line, and whether there were any changed lines at all.
+func (opts *StringOpts) contextMask(lines []diffLine) (show []bool, changed bool) {
+	return opts.contextMaskOf(len(lines), func(i int) bool {
+		return lines[i].kind != bothSegment
+	})
+}
+
+// contextMaskOf is contextMask for n rows of any kind, where isChanged reports
+// whether row i is changed.
+func (opts *StringOpts) contextMaskOf(n int, isChanged func(i int) bool) (show []bool, changed bool) {
+	ctx := opts.ContextLines.Value
+	show = make([]bool, n)
+	for i := 0; i < n; i++ {
+		if !isChanged(i) {
+			continue
+		}
+		changed = true
+		if ctx < 0 {
+			for j := range show {
+				show[j] = true
+			}
+			break
+		}
+		for j := max(0, i-ctx); j <= min(n-1, i+ctx); j++ {
+			show[j] = true
+		}
+	}
+	return show, changed
+}
+
+// renderLines renders segments one line per output line, with changed lines
+// marked with `-` or `+` and runs of unchanged lines beyond ContextLines
+// elided. markEOL adds a `\ No newline at end of file` marker to a changed final
+// line that is missing its newline.
+func (opts *StringOpts) renderLines(segs []segment, markEOL bool) (s string) {
+	var out []string
+	var elided bool
+
+	lines := toLines(segs)
+	show, changed := opts.contextMask(lines)
+	if !changed {
+		goto end
+	}
+	for i, line := range lines {
+		if !show[i] {
+			if !elided {
+				out = append(out, opts.style.Context(elidedLines))
+			}
+			elided = true
+			continue
+		}
+		elided = false
+		out = append(out, line.styled(opts.style))
+		if markEOL && !line.eol && line.kind != bothSegment {
+			out = append(out, opts.style.Context(noNewlineAtEOF))
+		}
+	}
+	s = strings.Join(out, "\n")
+end:
+	return s
+}
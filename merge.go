@@ -0,0 +1,196 @@
+package diffator
+
+import (
+	"slices"
+	"strings"
+)
+
+// Markers delimiting the sides of a conflict in a merged string.
+const (
+	OursMarker   = "<<<<<<<"
+	BaseMarker   = "|||||||"
+	SplitMarker  = "======="
+	TheirsMarker = ">>>>>>>"
+)
+
+// StringConflict is a region of a string merged by Merge3Strings() that ours
+// and theirs both changed, differently. Offset is the byte offset in the merged
+// string of the conflict's OursMarker.
+type StringConflict struct {
+	Offset int
+	Base   string
+	Ours   string
+	Theirs string
+}
+
+// Merge3Strings merges the changes made from base to ours and from base to
+// theirs, line-by-line or in RuneMode rune-by-rune, as `git merge-file` does.
+// Changes made on only one side, or made alike on both, are taken as is. Where
+// both sides changed the same region differently the merged string holds both
+// versions between conflict markers, e.g. in line mode:
+//
+//	<<<<<<< ours
+//	Port = 8080
+//	=======
+//	Port = 9090
+//	>>>>>>> theirs
+//
+// and in RuneMode `<<<<<<<8080=======9090>>>>>>>`. The conflicts are also
+// returned in order, so none are found if conflicts is empty.
+func Merge3Strings(base, ours, theirs string, opts *MergeOpts) (merged string, conflicts []StringConflict) {
+	var b, o, t tokenSeq
+
+	if opts == nil {
+		opts = &MergeOpts{}
+	}
+	opts.SetDefaults()
+	if opts.RuneMode.Value {
+		b, o, t = newRuneSeq(base), newRuneSeq(ours), newRuneSeq(theirs)
+	} else {
+		in := make(interner)
+		b = newTokenSeq(base, LineTokenizer, in)
+		o = newTokenSeq(ours, LineTokenizer, in)
+		t = newTokenSeq(theirs, LineTokenizer, in)
+	}
+	sb := strings.Builder{}
+	for _, c := range diff3Chunks(b.len(), o.len(), t.len(), tokenEq(b, o), tokenEq(b, t)) {
+		bs := b.slice(c.base[0], c.base[1])
+		os := o.slice(c.ours[0], c.ours[1])
+		ts := t.slice(c.theirs[0], c.theirs[1])
+		switch {
+		case c.stable, slices.Equal(os.ids, ts.ids):
+			sb.WriteString(os.String())
+		case slices.Equal(bs.ids, os.ids):
+			sb.WriteString(ts.String())
+		case slices.Equal(bs.ids, ts.ids):
+			sb.WriteString(os.String())
+		default:
+			conflicts = append(conflicts, StringConflict{
+				Offset: sb.Len(),
+				Base:   bs.String(),
+				Ours:   os.String(),
+				Theirs: ts.String(),
+			})
+			writeConflict(&sb, conflicts[len(conflicts)-1], opts)
+		}
+	}
+	return sb.String(), conflicts
+}
+
+// writeConflict writes c to sb between conflict markers, each on a line of its
+// own unless in RuneMode.
+func writeConflict(sb *strings.Builder, c StringConflict, opts *MergeOpts) {
+	if opts.RuneMode.Value {
+		sb.WriteString(OursMarker + c.Ours)
+		if opts.ShowBase.Value {
+			sb.WriteString(BaseMarker + c.Base)
+		}
+		sb.WriteString(SplitMarker + c.Theirs + TheirsMarker)
+		return
+	}
+	writeLines := func(s string) {
+		sb.WriteString(s)
+		if s != "" && !strings.HasSuffix(s, "\n") {
+			sb.WriteByte('\n')
+		}
+	}
+	sb.WriteString(OursMarker + " " + opts.OursLabel.Value + "\n")
+	writeLines(c.Ours)
+	if opts.ShowBase.Value {
+		sb.WriteString(BaseMarker + " " + opts.BaseLabel.Value + "\n")
+		writeLines(c.Base)
+	}
+	sb.WriteString(SplitMarker + "\n")
+	writeLines(c.Theirs)
+	sb.WriteString(TheirsMarker + " " + opts.TheirsLabel.Value + "\n")
+}
+
+func tokenEq(ts1, ts2 tokenSeq) func(i, j int) bool {
+	return func(i, j int) bool {
+		return ts1.ids[i] == ts2.ids[j]
+	}
+}
+
+// mergeChunk is a region of a three-way merge: the elements base[0] through
+// base[1]-1 of base, and likewise of ours and theirs. A stable chunk is equal
+// on all three sides; in an unstable chunk ours, theirs or both differ from
+// base.
+type mergeChunk struct {
+	stable bool
+	base   [2]int
+	ours   [2]int
+	theirs [2]int
+}
+
+// diff3Chunks splits three sequences, of n elements for base and n1 and n2
+// elements for ours and theirs, into alternating stable and unstable chunks,
+// after aligning base with ours and base with theirs, where eq1 and eq2
+// report whether base[i] equals ours[j] or theirs[j], respectively.
+func diff3Chunks(n, n1, n2 int, eq1, eq2 func(i, j int) bool) (chunks []mergeChunk) {
+	var i, j1, j2 int
+
+	to1 := alignedIndexes(n, n1, eq1)
+	to2 := alignedIndexes(n, n2, eq2)
+	for i < n || j1 < n1 || j2 < n2 {
+		k := 0
+		for i+k < n && to1[i+k] == j1+k && to2[i+k] == j2+k {
+			k++
+		}
+		if k > 0 {
+			chunks = append(chunks, mergeChunk{
+				stable: true,
+				base:   [2]int{i, i + k},
+				ours:   [2]int{j1, j1 + k},
+				theirs: [2]int{j2, j2 + k},
+			})
+			i, j1, j2 = i+k, j1+k, j2+k
+			continue
+		}
+		// The unstable chunk ends at the next element of base aligned with
+		// both ours and theirs, or else at the end of all three
+		end, end1, end2 := i, n1, n2
+		for end < n && (to1[end] == -1 || to2[end] == -1) {
+			end++
+		}
+		if end < n {
+			end1, end2 = to1[end], to2[end]
+		}
+		chunks = append(chunks, mergeChunk{
+			base:   [2]int{i, end},
+			ours:   [2]int{j1, end1},
+			theirs: [2]int{j2, end2},
+		})
+		i, j1, j2 = end, end1, end2
+	}
+	return chunks
+}
+
+// alignedIndexes aligns a want sequence of n elements with a got sequence of m
+// elements as alignElements() does, and returns for each want element the
+// index of the equal got element it is aligned with, or -1 if none.
+func alignedIndexes(n, m int, eq func(i, j int) bool) []int {
+	differs1 := make([]bool, n)
+	differs2 := make([]bool, m)
+	for _, op := range alignElements(n, m, eq) {
+		if op.want != -1 {
+			differs1[op.want] = true
+		}
+		if op.got != -1 {
+			differs2[op.got] = true
+		}
+	}
+	indexes := make([]int, n)
+	j := 0
+	for i := range indexes {
+		if differs1[i] {
+			indexes[i] = -1
+			continue
+		}
+		for differs2[j] {
+			j++
+		}
+		indexes[i] = j
+		j++
+	}
+	return indexes
+}
@@ -0,0 +1,399 @@
+package diffator
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// MergeConflictKind classifies a MergeConflict.
+type MergeConflictKind int
+
+const (
+	// BothChangedConflict means ours and theirs both changed a value of base,
+	// differently.
+	BothChangedConflict MergeConflictKind = iota
+	// ChangedDeletedConflict means one of ours and theirs changed a value of
+	// base, such as a map entry, and the other deleted it.
+	ChangedDeletedConflict
+	// BothAddedConflict means ours and theirs both added a value not found in
+	// base, differently.
+	BothAddedConflict
+)
+
+func (k MergeConflictKind) String() (s string) {
+	switch k {
+	case BothChangedConflict:
+		s = "both-changed"
+	case ChangedDeletedConflict:
+		s = "changed-deleted"
+	case BothAddedConflict:
+		s = "both-added"
+	default:
+		s = "unknown"
+	}
+	return s
+}
+
+// MergeConflict is a value that Merge3Objects() could not merge, found at Path
+// in base. Base, Ours and Theirs hold its value on each side, which is the zero
+// reflect.Value on a side that does not have it. For a run of slice elements
+// they are the elements of each side in that run, and Path is that of the run's
+// first element in base.
+//
+// Redacted is true for a field tagged `diff:"redact"`, whose values String()
+// masks, as it does such fields within the structs and slices it shows.
+type MergeConflict struct {
+	Kind     MergeConflictKind
+	Path     Path
+	Base     reflect.Value
+	Ours     reflect.Value
+	Theirs   reflect.Value
+	Redacted bool
+}
+
+// String returns the conflict as e.g.
+// `Port: both-changed: base 80, ours 8080, theirs 9090`.
+func (c MergeConflict) String() string {
+	path := c.Path.String()
+	if path == "" {
+		path = "<root>"
+	}
+	side := func(rv reflect.Value) string {
+		switch {
+		case !rv.IsValid():
+			return "<missing>"
+		case c.Redacted:
+			return Redacted
+		}
+		return opaqueString(rv)
+	}
+	return fmt.Sprintf("%s: %s: base %s, ours %s, theirs %s",
+		path, c.Kind, side(c.Base), side(c.Ours), side(c.Theirs),
+	)
+}
+
+// Merge3Objects merges the changes made from base to ours and from base to
+// theirs, as found by comparing them with opts, and returns the merged value
+// and the conflicts found, in order.
+//
+// Changes made on only one side, or made alike on both, are taken as is, and
+// structs, maps, pointers and interfaces changed on both sides are merged value
+// by value. Slices and arrays are merged element by element after aligning
+// their elements, or by key for elements with a SliceKey in opts, where the
+// elements only theirs added follow those of ours. Values with a comparer, such
+// as time.Time, are merged whole. Where both sides changed a value differently
+// the merged value holds ours and the conflict is returned.
+//
+// The merged value does not share memory with base, ours or theirs.
+func Merge3Objects[T any](base, ours, theirs T, opts *ObjectOpts) (merged T, conflicts []MergeConflict) {
+	m := newMerger(opts)
+	rv := m.merge(
+		reflect.ValueOf(&base).Elem(),
+		reflect.ValueOf(&ours).Elem(),
+		reflect.ValueOf(&theirs).Elem(),
+		nil,
+	)
+	if rv.IsValid() {
+		reflect.ValueOf(&merged).Elem().Set(rv)
+	}
+	return merged, m.conflicts
+}
+
+// merger merges three values, using its ObjectComparator to find where they
+// differ.
+type merger struct {
+	*ObjectComparator
+	conflicts []MergeConflict
+	// seen maps the base, ours and theirs pointers already merged to the
+	// merged pointer, so cycles are preserved.
+	seen map[[3]uintptr]reflect.Value
+}
+
+func newMerger(opts *ObjectOpts) *merger {
+	return &merger{
+		ObjectComparator: NewObjectComparator(nil, nil, opts),
+		seen:             make(map[[3]uintptr]reflect.Value),
+	}
+}
+
+// equal returns true if rv1 and rv2 compare equal, including if both are
+// missing.
+func (m *merger) equal(rv1, rv2 reflect.Value, path Path) (eq bool) {
+	switch {
+	case !rv1.IsValid() || !rv2.IsValid():
+		eq = rv1.IsValid() == rv2.IsValid()
+	case rv1.Type() != rv2.Type():
+	default:
+		eq = m.diffValues(&rv1, &rv2, path) == nil
+	}
+	return eq
+}
+
+// merge returns a copy of the value that merges the changes from base to ours
+// and from base to theirs, or the zero reflect.Value if the merged value is
+// missing, such as a map entry deleted on one side.
+func (m *merger) merge(base, ours, theirs reflect.Value, path Path) (merged reflect.Value) {
+	switch {
+	case m.equal(ours, theirs, path):
+		merged = ours
+	case m.equal(base, ours, path):
+		merged = theirs
+	case m.equal(base, theirs, path):
+		merged = ours
+	default:
+		merged = m.mergeChanged(base, ours, theirs, path)
+		goto end
+	}
+	if merged.IsValid() {
+		merged = copyOf(merged)
+	}
+end:
+	return merged
+}
+
+// mergeChanged merges values that ours and theirs both changed differently.
+func (m *merger) mergeChanged(base, ours, theirs reflect.Value, path Path) (merged reflect.Value) {
+	var handled bool
+
+	if !base.IsValid() || !ours.IsValid() || !theirs.IsValid() {
+		goto conflict
+	}
+	if ours.Type() != base.Type() || theirs.Type() != base.Type() {
+		goto conflict
+	}
+	_, handled = m.customDiff(&base, &ours, path)
+	if handled || isBytesType(base.Type()) {
+		goto conflict
+	}
+	switch base.Kind() {
+	case reflect.Pointer:
+		if base.IsNil() || ours.IsNil() || theirs.IsNil() {
+			goto conflict
+		}
+		merged = m.mergePointers(base, ours, theirs, path)
+	case reflect.Interface:
+		if base.IsNil() || ours.IsNil() || theirs.IsNil() {
+			goto conflict
+		}
+		if ours.Elem().Type() != base.Elem().Type() || theirs.Elem().Type() != base.Elem().Type() {
+			goto conflict
+		}
+		merged = reflect.New(base.Type()).Elem()
+		merged.Set(m.merge(base.Elem(), ours.Elem(), theirs.Elem(), path))
+	case reflect.Struct:
+		merged = m.mergeStructs(base, ours, theirs, path)
+	case reflect.Map:
+		merged = m.mergeMaps(base, ours, theirs, path)
+	case reflect.Array:
+		merged = reflect.New(base.Type()).Elem()
+		for i := 0; i < base.Len(); i++ {
+			merged.Index(i).Set(m.merge(base.Index(i), ours.Index(i), theirs.Index(i), path.withIndex(i)))
+		}
+	case reflect.Slice:
+		merged = m.mergeSlices(base, ours, theirs, path)
+	default:
+		goto conflict
+	}
+	goto end
+conflict:
+	m.addConflict(base, ours, theirs, path)
+	if ours.IsValid() {
+		merged = copyOf(ours)
+	}
+end:
+	return merged
+}
+
+// addConflict records a conflict between base, ours and theirs at path.
+func (m *merger) addConflict(base, ours, theirs reflect.Value, path Path) {
+	kind := BothChangedConflict
+	switch {
+	case !base.IsValid():
+		kind = BothAddedConflict
+	case !ours.IsValid() || !theirs.IsValid():
+		kind = ChangedDeletedConflict
+	}
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Kind:     kind,
+		Path:     path,
+		Base:     base,
+		Ours:     ours,
+		Theirs:   theirs,
+		Redacted: m.tag.redact && m.tagDepth <= len(path),
+	})
+}
+
+func (m *merger) mergePointers(base, ours, theirs reflect.Value, path Path) reflect.Value {
+	key := [3]uintptr{base.Pointer(), ours.Pointer(), theirs.Pointer()}
+	ptr, ok := m.seen[key]
+	if ok {
+		return ptr
+	}
+	ptr = reflect.New(base.Type().Elem())
+	m.seen[key] = ptr
+	ptr.Elem().Set(m.merge(base.Elem(), ours.Elem(), theirs.Elem(), path))
+	return ptr
+}
+
+// mergeStructs merges each field, honoring the fields' `diff` tags. Fields
+// tagged `diff:"-"` are taken from ours.
+func (m *merger) mergeStructs(base, ours, theirs reflect.Value, path Path) reflect.Value {
+	merged := reflect.New(base.Type()).Elem()
+	parentTag, parentDepth := m.tag, m.tagDepth
+	for i, tag := range structTags(base.Type()) {
+		fld := settable(merged.Field(i))
+		if tag.skip {
+			fld.Set(copyOf(ours.Field(i)))
+			continue
+		}
+		fldPath := path.withField(tag.name, i)
+		m.tag, m.tagDepth = tag, len(fldPath)
+		fld.Set(m.merge(base.Field(i), ours.Field(i), theirs.Field(i), fldPath))
+	}
+	m.tag, m.tagDepth = parentTag, parentDepth
+	return merged
+}
+
+// mergeMaps merges the entries of each key of ours, and then of each key of
+// theirs not found in ours.
+func (m *merger) mergeMaps(base, ours, theirs reflect.Value, path Path) reflect.Value {
+	merged := reflect.MakeMapWithSize(base.Type(), ours.Len())
+	mergeKey := func(key reflect.Value) {
+		val := m.merge(base.MapIndex(key), ours.MapIndex(key), theirs.MapIndex(key), path.withKey(key))
+		if val.IsValid() {
+			merged.SetMapIndex(copyOf(key), val)
+		}
+	}
+	for _, key := range NewTrackerWithKeys(&ours).SortedKeys {
+		mergeKey(key)
+	}
+	for _, key := range NewTrackerWithKeys(&theirs).SortedKeys {
+		if !ours.MapIndex(key).IsValid() {
+			mergeKey(key)
+		}
+	}
+	return merged
+}
+
+// mergeSlices merges the elements of slices by key if a SliceKey is registered
+// for them, or else by aligning the elements of ours and of theirs with those
+// of base.
+func (m *merger) mergeSlices(base, ours, theirs reflect.Value, path Path) (merged reflect.Value) {
+	var ok bool
+
+	sk, deref, keyed := m.sliceKey(base.Type())
+	if keyed {
+		merged, ok = m.mergeKeyed(base, ours, theirs, path, sk, deref)
+		if ok {
+			goto end
+		}
+	}
+	merged = m.mergeElements(base, ours, theirs, path)
+end:
+	return merged
+}
+
+// mergeElements merges runs of elements found between the elements that base,
+// ours and theirs have in common. A run changed differently on both sides is
+// merged element by element if it has as many elements on each side, and is
+// otherwise a conflict.
+func (m *merger) mergeElements(base, ours, theirs reflect.Value, path Path) reflect.Value {
+	eq := func(s reflect.Value) func(i, j int) bool {
+		return func(i, j int) bool {
+			return m.equal(base.Index(i), s.Index(j), path.withIndex(i))
+		}
+	}
+	merged := reflect.MakeSlice(base.Type(), 0, ours.Len())
+	appendAll := func(s reflect.Value) {
+		for i := 0; i < s.Len(); i++ {
+			merged = reflect.Append(merged, copyOf(s.Index(i)))
+		}
+	}
+	chunks := diff3Chunks(base.Len(), ours.Len(), theirs.Len(), eq(ours), eq(theirs))
+	for _, c := range chunks {
+		bs := base.Slice(c.base[0], c.base[1])
+		os := ours.Slice(c.ours[0], c.ours[1])
+		ts := theirs.Slice(c.theirs[0], c.theirs[1])
+		switch {
+		case c.stable, m.equal(os, ts, path):
+			appendAll(os)
+		case m.equal(bs, os, path):
+			appendAll(ts)
+		case m.equal(bs, ts, path):
+			appendAll(os)
+		case bs.Len() == os.Len() && os.Len() == ts.Len():
+			for k := 0; k < bs.Len(); k++ {
+				elem := m.merge(bs.Index(k), os.Index(k), ts.Index(k), path.withIndex(c.base[0]+k))
+				merged = reflect.Append(merged, elem)
+			}
+		default:
+			m.addElementsConflict(bs, os, ts, path.withIndex(c.base[0]))
+			appendAll(os)
+		}
+	}
+	return merged
+}
+
+// addElementsConflict records a conflict between runs of elements, any of
+// which may be empty where elements were only deleted or only inserted.
+func (m *merger) addElementsConflict(bs, os, ts reflect.Value, path Path) {
+	m.addConflict(bs, os, ts, path)
+	c := &m.conflicts[len(m.conflicts)-1]
+	switch {
+	case bs.Len() == 0:
+		c.Kind = BothAddedConflict
+	case os.Len() == 0 || ts.Len() == 0:
+		c.Kind = ChangedDeletedConflict
+	}
+}
+
+// mergeKeyed merges the elements of slices with equal keys per sk, ordered as
+// in ours followed by those only theirs added. ok is false if the elements of
+// any slice cannot be matched by key, as for diffKeyed().
+func (m *merger) mergeKeyed(base, ours, theirs reflect.Value, path Path, sk SliceKey, deref bool) (merged reflect.Value, ok bool) {
+	var keys1, keys2 []any
+	var index0, index1, index2 map[any]int
+
+	_, index0, ok = elementKeys(&base, sk, deref)
+	if !ok {
+		goto end
+	}
+	keys1, index1, ok = elementKeys(&ours, sk, deref)
+	if !ok {
+		goto end
+	}
+	keys2, index2, ok = elementKeys(&theirs, sk, deref)
+	if !ok {
+		goto end
+	}
+	merged = reflect.MakeSlice(base.Type(), 0, ours.Len())
+	for j, key := range append(keys1[:len(keys1):len(keys1)], keys2...) {
+		if j >= len(keys1) {
+			if _, found := index1[key]; found {
+				continue
+			}
+		}
+		i, found := index0[key]
+		if !found {
+			i, found = index1[key]
+		}
+		if !found {
+			i = index2[key]
+		}
+		keyPath := path.withElementKey(sk.name, reflect.ValueOf(key), i)
+		val := m.merge(keyedElement(base, index0, key), keyedElement(ours, index1, key), keyedElement(theirs, index2, key), keyPath)
+		if val.IsValid() {
+			merged = reflect.Append(merged, val)
+		}
+	}
+end:
+	return merged, ok
+}
+
+// keyedElement returns the element of s with key per index, if any.
+func keyedElement(s reflect.Value, index map[any]int, key any) (elem reflect.Value) {
+	if i, found := index[key]; found {
+		elem = s.Index(i)
+	}
+	return elem
+}
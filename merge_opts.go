@@ -0,0 +1,33 @@
+package diffator
+
+type MergeOpts struct {
+	// RuneMode merges strings rune-by-rune rather than line-by-line, and marks
+	// conflicts inline rather than on lines of their own.
+	RuneMode *BoolValue
+	// ShowBase includes the base side of each conflict between `|||||||` and
+	// `=======` markers, as `git merge-file --diff3` does.
+	ShowBase *BoolValue
+	// OursLabel, BaseLabel and TheirsLabel follow the `<<<<<<<`, `|||||||`
+	// and `>>>>>>>` markers of conflicts in line mode.
+	OursLabel   *StringValue
+	BaseLabel   *StringValue
+	TheirsLabel *StringValue
+}
+
+func (opts *MergeOpts) SetDefaults() {
+	if opts.RuneMode == nil {
+		opts.RuneMode = Bool(false)
+	}
+	if opts.ShowBase == nil {
+		opts.ShowBase = Bool(false)
+	}
+	if opts.OursLabel == nil {
+		opts.OursLabel = String("ours")
+	}
+	if opts.BaseLabel == nil {
+		opts.BaseLabel = String("base")
+	}
+	if opts.TheirsLabel == nil {
+		opts.TheirsLabel = String("theirs")
+	}
+}
@@ -0,0 +1,322 @@
+package diffator_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge3Strings(t *testing.T) {
+	base := "host = localhost\nport = 80\nuser = admin\nlog = info\n"
+
+	tests := []struct {
+		name          string
+		base          string
+		ours          string
+		theirs        string
+		opts          *diffator.MergeOpts
+		wantMerged    string
+		wantConflicts []diffator.StringConflict
+	}{
+		{
+			name:       "Changes on different lines",
+			base:       base,
+			ours:       "host = example.com\nport = 80\nuser = admin\nlog = info\n",
+			theirs:     "host = localhost\nport = 80\nuser = admin\nlog = debug\n",
+			wantMerged: "host = example.com\nport = 80\nuser = admin\nlog = debug\n",
+		},
+		{
+			name:       "Insertions and deletions",
+			base:       base,
+			ours:       "# config\nhost = localhost\nport = 80\nuser = admin\nlog = info\n",
+			theirs:     "host = localhost\nport = 80\nlog = info\ntls = on\n",
+			wantMerged: "# config\nhost = localhost\nport = 80\nlog = info\ntls = on\n",
+		},
+		{
+			name:       "Same change on both sides",
+			base:       base,
+			ours:       "host = localhost\nport = 8080\nuser = admin\nlog = info\n",
+			theirs:     "host = localhost\nport = 8080\nuser = admin\nlog = debug\n",
+			wantMerged: "host = localhost\nport = 8080\nuser = admin\nlog = debug\n",
+		},
+		{
+			name:   "Conflict",
+			base:   base,
+			ours:   "host = localhost\nport = 8080\nuser = admin\nlog = info\n",
+			theirs: "host = localhost\nport = 9090\nuser = admin\nlog = debug\n",
+			wantMerged: "host = localhost\n" +
+				"<<<<<<< ours\nport = 8080\n=======\nport = 9090\n>>>>>>> theirs\n" +
+				"user = admin\nlog = debug\n",
+			wantConflicts: []diffator.StringConflict{
+				{Offset: 17, Base: "port = 80\n", Ours: "port = 8080\n", Theirs: "port = 9090\n"},
+			},
+		},
+		{
+			name:   "Conflict with base and labels",
+			base:   "a\nb",
+			ours:   "a\nc",
+			theirs: "a\nd",
+			opts: &diffator.MergeOpts{
+				ShowBase:    diffator.Bool(true),
+				OursLabel:   diffator.String("user"),
+				TheirsLabel: diffator.String("upstream"),
+			},
+			wantMerged: "a\n<<<<<<< user\nc\n||||||| base\nb\n=======\nd\n>>>>>>> upstream\n",
+			wantConflicts: []diffator.StringConflict{
+				{Offset: 2, Base: "b", Ours: "c", Theirs: "d"},
+			},
+		},
+		{
+			name:       "Conflict between insertions at the end",
+			base:       "a\n",
+			ours:       "a\nb\n",
+			theirs:     "a\nc\n",
+			wantMerged: "a\n<<<<<<< ours\nb\n=======\nc\n>>>>>>> theirs\n",
+			wantConflicts: []diffator.StringConflict{
+				{Offset: 2, Ours: "b\n", Theirs: "c\n"},
+			},
+		},
+		{
+			name:       "Rune mode",
+			base:       "Hello World, bye!",
+			ours:       "Hello Gopher, bye!",
+			theirs:     "Hello World, bye?",
+			opts:       &diffator.MergeOpts{RuneMode: diffator.Bool(true)},
+			wantMerged: "Hello Gopher, bye?",
+		},
+		{
+			name:       "Rune mode conflict",
+			base:       "port=1;",
+			ours:       "port=2;",
+			theirs:     "port=3;",
+			opts:       &diffator.MergeOpts{RuneMode: diffator.Bool(true), ShowBase: diffator.Bool(true)},
+			wantMerged: "port=<<<<<<<2|||||||1=======3>>>>>>>;",
+			wantConflicts: []diffator.StringConflict{
+				{Offset: 5, Base: "1", Ours: "2", Theirs: "3"},
+			},
+		},
+		{
+			name:       "Empty base",
+			base:       "",
+			ours:       "a\n",
+			theirs:     "a\n",
+			wantMerged: "a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := diffator.Merge3Strings(tt.base, tt.ours, tt.theirs, tt.opts)
+			assert.Equal(t, tt.wantMerged, merged)
+			assert.Equal(t, tt.wantConflicts, conflicts)
+		})
+	}
+}
+
+type MergeConfig struct {
+	Name     string
+	Port     int
+	Tags     []string
+	Env      map[string]string
+	Owner    *PatchOwner
+	Users    []KeyedUser
+	Updated  time.Time
+	Password string `diff:"redact"`
+	Admins   []RedactedOwner
+	Lead     *RedactedOwner
+	private  int
+}
+
+func newMergeConfig() MergeConfig {
+	return MergeConfig{
+		Name:     "web",
+		Port:     80,
+		Tags:     []string{"a", "b", "c"},
+		Env:      map[string]string{"A": "1", "B": "2"},
+		Owner:    &PatchOwner{Email: "a@x"},
+		Users:    []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}},
+		Updated:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Password: "secret",
+		Admins:   []RedactedOwner{{Name: "a", Password: "secret"}},
+		private:  1,
+	}
+}
+
+func TestMerge3Objects(t *testing.T) {
+	later := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		ours          func(c *MergeConfig)
+		theirs        func(c *MergeConfig)
+		opts          *diffator.ObjectOpts
+		want          func(c *MergeConfig)
+		wantConflicts []string
+	}{
+		{
+			name:   "Changes to different fields",
+			ours:   func(c *MergeConfig) { c.Name = "api"; c.private = 2 },
+			theirs: func(c *MergeConfig) { c.Port = 8080; c.Owner = &PatchOwner{Email: "b@x"} },
+			want: func(c *MergeConfig) {
+				c.Name = "api"
+				c.private = 2
+				c.Port = 8080
+				c.Owner = &PatchOwner{Email: "b@x"}
+			},
+		},
+		{
+			name:   "Same change on both sides",
+			ours:   func(c *MergeConfig) { c.Port = 8080 },
+			theirs: func(c *MergeConfig) { c.Port = 8080; c.Name = "api" },
+			want:   func(c *MergeConfig) { c.Port = 8080; c.Name = "api" },
+		},
+		{
+			name:   "Field conflict",
+			ours:   func(c *MergeConfig) { c.Port = 8080; c.Name = "api" },
+			theirs: func(c *MergeConfig) { c.Port = 9090 },
+			want:   func(c *MergeConfig) { c.Port = 8080; c.Name = "api" },
+			wantConflicts: []string{
+				"Port: both-changed: base 80, ours 8080, theirs 9090",
+			},
+		},
+		{
+			name:   "Pointed-to fields",
+			ours:   func(c *MergeConfig) { c.Owner.Email = "o@x" },
+			theirs: func(c *MergeConfig) { c.Owner.Email = "t@x" },
+			want:   func(c *MergeConfig) { c.Owner.Email = "o@x" },
+			wantConflicts: []string{
+				`Owner.Email: both-changed: base "a@x", ours "o@x", theirs "t@x"`,
+			},
+		},
+		{
+			name:   "Map entries",
+			ours:   func(c *MergeConfig) { c.Env = map[string]string{"A": "1", "B": "3", "C": "4"} },
+			theirs: func(c *MergeConfig) { c.Env = map[string]string{"B": "2", "D": "5"} },
+			want:   func(c *MergeConfig) { c.Env = map[string]string{"B": "3", "C": "4", "D": "5"} },
+		},
+		{
+			name:   "Map entry changed and deleted",
+			ours:   func(c *MergeConfig) { c.Env = map[string]string{"A": "9", "B": "2"} },
+			theirs: func(c *MergeConfig) { c.Env = map[string]string{"B": "2", "C": "3"} },
+			want:   func(c *MergeConfig) { c.Env = map[string]string{"A": "9", "B": "2", "C": "3"} },
+			wantConflicts: []string{
+				`Env["A"]: changed-deleted: base "1", ours "9", theirs <missing>`,
+			},
+		},
+		{
+			name:   "Map entry added on both sides",
+			ours:   func(c *MergeConfig) { c.Env["C"] = "3" },
+			theirs: func(c *MergeConfig) { c.Env["C"] = "4" },
+			want:   func(c *MergeConfig) { c.Env["C"] = "3" },
+			wantConflicts: []string{
+				`Env["C"]: both-added: base <missing>, ours "3", theirs "4"`,
+			},
+		},
+		{
+			name:   "Slice elements",
+			ours:   func(c *MergeConfig) { c.Tags = []string{"x", "a", "b", "c"} },
+			theirs: func(c *MergeConfig) { c.Tags = []string{"a", "c", "y"} },
+			want:   func(c *MergeConfig) { c.Tags = []string{"x", "a", "c", "y"} },
+		},
+		{
+			name:   "Slice element conflict",
+			ours:   func(c *MergeConfig) { c.Tags = []string{"a", "B", "c"} },
+			theirs: func(c *MergeConfig) { c.Tags = []string{"a", "b2", "b3", "c"} },
+			want:   func(c *MergeConfig) { c.Tags = []string{"a", "B", "c"} },
+			wantConflicts: []string{
+				`Tags[1]: both-changed: base []string{"b",}, ours []string{"B",}, theirs []string{"b2","b3",}`,
+			},
+		},
+		{
+			name:   "Slice elements by key",
+			ours:   func(c *MergeConfig) { c.Users = []KeyedUser{{ID: 2, Email: "b@x"}, {ID: 1, Email: "o@x"}} },
+			theirs: func(c *MergeConfig) { c.Users = []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 3, Email: "c@x"}} },
+			opts:   &diffator.ObjectOpts{SliceKeys: []diffator.SliceKey{diffator.KeyField[KeyedUser]("ID")}},
+			want: func(c *MergeConfig) {
+				c.Users = []KeyedUser{{ID: 1, Email: "o@x"}, {ID: 3, Email: "c@x"}}
+			},
+		},
+		{
+			name:   "Values with a comparer are merged whole",
+			ours:   func(c *MergeConfig) { c.Updated = later },
+			theirs: func(c *MergeConfig) { c.Updated = later.Add(time.Hour) },
+			want:   func(c *MergeConfig) { c.Updated = later },
+			wantConflicts: []string{
+				"Updated: both-changed: base 2024-01-01 00:00:00 +0000 UTC, ours 2024-02-01 00:00:00 +0000 UTC, theirs 2024-02-01 01:00:00 +0000 UTC",
+			},
+		},
+		{
+			name:   "Redacted conflict",
+			ours:   func(c *MergeConfig) { c.Password = "ours" },
+			theirs: func(c *MergeConfig) { c.Password = "theirs" },
+			want:   func(c *MergeConfig) { c.Password = "ours" },
+			wantConflicts: []string{
+				"Password: both-changed: base <redacted>, ours <redacted>, theirs <redacted>",
+			},
+		},
+		{
+			name: "Nested redacted fields",
+			ours: func(c *MergeConfig) {
+				c.Admins = []RedactedOwner{{Name: "o", Password: "ours"}, {Name: "o2", Password: "ours2"}}
+				c.Lead = &RedactedOwner{Name: "o", Password: "ours"}
+			},
+			theirs: func(c *MergeConfig) {
+				c.Admins = []RedactedOwner{{Name: "t", Password: "theirs"}}
+				c.Lead = &RedactedOwner{Name: "t", Password: "theirs"}
+			},
+			want: func(c *MergeConfig) {
+				c.Admins = []RedactedOwner{{Name: "o", Password: "ours"}, {Name: "o2", Password: "ours2"}}
+				c.Lead = &RedactedOwner{Name: "o", Password: "ours"}
+			},
+			wantConflicts: []string{
+				`Admins[0]: both-changed: base []diffator_test.RedactedOwner{diffator_test.RedactedOwner{Name:"a",Password:<redacted>,},}, ` +
+					`ours []diffator_test.RedactedOwner{diffator_test.RedactedOwner{Name:"o",Password:<redacted>,},diffator_test.RedactedOwner{Name:"o2",Password:<redacted>,},}, ` +
+					`theirs []diffator_test.RedactedOwner{diffator_test.RedactedOwner{Name:"t",Password:<redacted>,},}`,
+				`Lead: both-changed: base *nil, ours *diffator_test.RedactedOwner{Name:"o",Password:<redacted>,}, ` +
+					`theirs *diffator_test.RedactedOwner{Name:"t",Password:<redacted>,}`,
+			},
+		},
+		{
+			name:   "Ignored fields are taken from ours",
+			ours:   func(c *MergeConfig) { c.Port = 8080 },
+			theirs: func(c *MergeConfig) { c.Port = 9090 },
+			opts:   &diffator.ObjectOpts{Ignore: []diffator.IgnoreRule{diffator.IgnorePath("Port")}},
+			want:   func(c *MergeConfig) { c.Port = 8080 },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, ours, theirs, want := newMergeConfig(), newMergeConfig(), newMergeConfig(), newMergeConfig()
+			tt.ours(&ours)
+			tt.theirs(&theirs)
+			tt.want(&want)
+			merged, conflicts := diffator.Merge3Objects(base, ours, theirs, tt.opts)
+			assert.Equal(t, "", diffator.CompareObjects(want, merged, nil))
+			var got []string
+			for _, c := range conflicts {
+				got = append(got, c.String())
+			}
+			assert.Equal(t, tt.wantConflicts, got)
+			assert.Equal(t, newMergeConfig(), base)
+		})
+	}
+}
+
+func TestMerge3ObjectsDoesNotShareMemory(t *testing.T) {
+	base, ours, theirs := newMergeConfig(), newMergeConfig(), newMergeConfig()
+	ours.Name = "api"
+	theirs.Port = 8080
+	merged, conflicts := diffator.Merge3Objects(&base, &ours, &theirs, nil)
+	assert.Empty(t, conflicts)
+	assert.Equal(t, "api", merged.Name)
+	assert.Equal(t, 8080, merged.Port)
+	merged.Tags[0] = "z"
+	merged.Env["A"] = "z"
+	merged.Owner.Email = "z"
+	for _, c := range []MergeConfig{base, ours, theirs} {
+		assert.Equal(t, newMergeConfig().Tags, c.Tags)
+		assert.Equal(t, newMergeConfig().Env, c.Env)
+		assert.Equal(t, newMergeConfig().Owner, c.Owner)
+	}
+}
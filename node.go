@@ -7,37 +7,34 @@ import (
 var _ fixer = (*node)(nil)
 
 type node struct {
-	left  []rune
-	both  []rune
-	right []rune
+	left  string
+	both  string
+	right string
 	opts  *StringOpts
 }
 
 func (n *node) InsertBoth(s string) {
-	n.both = append([]rune(s), n.both...)
+	n.both = s + n.both
 }
 
 func newNode(opts *StringOpts) *node {
 	return &node{
-		left:  make([]rune, 0),
-		both:  make([]rune, 0),
-		right: make([]rune, 0),
-		opts:  opts,
+		opts: opts,
 	}
 }
 
 func (*node) Fixer() {}
 
 func (n *node) AddLeft(s string) {
-	n.left = append(n.left, []rune(s)...)
+	n.left += s
 }
 
 func (n *node) AddBoth(s string) {
-	n.both = append(n.both, []rune(s)...)
+	n.both += s
 }
 
 func (n *node) AddRight(s string) {
-	n.right = append(n.right, []rune(s)...)
+	n.right += s
 }
 
 func (n *node) bitMap() (bits int8) {
@@ -55,17 +52,31 @@ func (n *node) bitMap() (bits int8) {
 
 func (n *node) String() (s string) {
 	format := n.opts.LeftRightFormat
+	st := n.opts.style
 	switch n.bitMap() {
 	case 0b000:
 		s = ""
 	case 0b010:
-		s = string(n.both)
+		s = st.Context(n.both)
 	case 0b101, 0b100, 0b001:
-		s = fmt.Sprintf(format.Value, string(n.left), string(n.right))
+		s = fmt.Sprintf(format.Value, st.Want(n.left), st.Got(n.right))
 	case 0b111, 0b110, 0b011:
 		s = fmt.Sprintf(format.Value+"%s"+format.Value,
-			string(n.left), "", string(n.both), "", string(n.right),
+			st.Want(n.left), "", st.Context(n.both), "", st.Got(n.right),
 		)
 	}
 	return s
 }
+
+func (n *node) segments() (segs []segment) {
+	if len(n.left) > 0 {
+		segs = append(segs, segment{kind: leftSegment, text: n.left})
+	}
+	if len(n.both) > 0 {
+		segs = append(segs, segment{kind: bothSegment, text: n.both})
+	}
+	if len(n.right) > 0 {
+		segs = append(segs, segment{kind: rightSegment, text: n.right})
+	}
+	return segs
+}
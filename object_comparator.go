@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"unicode/utf8"
 )
 
 var _ Comparator = (*ObjectComparator)(nil)
@@ -14,6 +15,10 @@ type ObjectComparator struct {
 	level   int
 	tracker *Tracker
 	opts    *ObjectOpts
+	// tag holds the `diff` tag options of the struct field being compared, and
+	// tagDepth the length of that field's path.
+	tag      fieldTag
+	tagDepth int
 }
 
 func NewObjectComparator(v1, v2 any, opts *ObjectOpts) *ObjectComparator {
@@ -29,49 +34,93 @@ func NewObjectComparator(v1, v2 any, opts *ObjectOpts) *ObjectComparator {
 }
 
 func (o *ObjectComparator) Compare() string {
-	return o.compare(o.values[0], o.values[1], o.opts.OutputFormat.Value)
+	return o.Render(o.Result())
 }
 
-func (o *ObjectComparator) compare(v1, v2 any, format string) string {
-	switch v1.(type) {
-	case reflect.Value, *reflect.Value:
-		// We got what we need, do nothing
+// Result compares the comparator's values and returns the tree of differences
+// found, or nil if there are none.
+func (o *ObjectComparator) Result() *Diff {
+	rv1, rv2 := o.reflectValues(o.values[0], o.values[1])
+	d := o.diffValues(&rv1, &rv2, nil)
+	d.setOpts(o.opts)
+	return d
+}
+
+// Render returns the string form of a Diff using the comparator's options.
+func (o *ObjectComparator) Render(d *Diff) (diff string) {
+	switch {
+	case d != nil && o.opts.SideBySide.Value:
+		diff = fmt.Sprintf(o.opts.OutputFormat.Value, o.renderSideBySide(d))
 	default:
-		v1 = reflect.ValueOf(v1)
+		diff = o.renderDiff(d, o.opts.OutputFormat.Value)
 	}
-	switch v2.(type) {
-	case reflect.Value, *reflect.Value:
-		// We got what we need, do nothing
-	default:
-		v2 = reflect.ValueOf(v2)
+	if o.opts.PrettyPrint.Value && diff != "" {
+		diff = "\n" + diff
 	}
+	return diff
+}
+
+func (o *ObjectComparator) reflectValues(v1, v2 any) (rv1, rv2 reflect.Value) {
+	rv1 = addressable(toReflectValue(v1))
+	rv2 = addressable(toReflectValue(v2))
 	// Copy original values to ensure they are available during debugging, or if
 	// needed later for other things.
-	o.values[0] = v1
-	o.values[1] = v2
-	rv1 := v1.(reflect.Value)
-	rv2 := v2.(reflect.Value)
-	return o.ReflectValuesDiff(&rv1, &rv2, format)
+	o.values[0] = rv1
+	o.values[1] = rv2
+	return rv1, rv2
+}
+
+func toReflectValue(v any) (rv reflect.Value) {
+	switch t := v.(type) {
+	case reflect.Value:
+		rv = t
+	case *reflect.Value:
+		rv = *t
+	default:
+		rv = reflect.ValueOf(v)
+	}
+	return rv
+}
+
+// addressable returns rv, or a copy of it if it is not addressable, so that
+// the unexported fields within it can be passed to comparers and Equal methods.
+func addressable(rv reflect.Value) reflect.Value {
+	if !rv.IsValid() || rv.CanAddr() {
+		return rv
+	}
+	cp := reflect.New(rv.Type()).Elem()
+	cp.Set(rv)
+	return cp
 }
 
 func (o *ObjectComparator) ReflectValuesDiff(rv1, rv2 *reflect.Value, format string) (diff string) {
-	var sb strings.Builder
+	a1, a2 := addressable(*rv1), addressable(*rv2)
+	return o.renderDiff(o.diffValues(&a1, &a2, nil), format)
+}
+
+// diffValues builds the tree of differences between rv1 and rv2, where path is
+// the location of rv1 and rv2 relative to the root of the comparison.
+func (o *ObjectComparator) diffValues(rv1, rv2 *reflect.Value, path Path) (d *Diff) {
 	var alreadySeen bool
+	var handled bool
 	var id ValueId
 
-	opts := o.opts
+	if o.ignored(path, rv1, rv2) {
+		goto end
+	}
 
-	if !o.checkValid(rv1, rv2, sb) {
-		diff = "<invalid>"
+	if rv1.IsValid() != rv2.IsValid() {
+		d = newDiff(InvalidDiff, path, rv1, rv2)
 		goto end
 	}
 
-	if !o.checkKind(rv1, rv2, sb) {
-		diff = fmt.Sprintf("<type-mismatch>:%s", o.notEqualDiff(
-			rv1.Type(),
-			NewReflector(rv1).String(),
-			NewReflector(rv2).String(),
-		))
+	if rv1.Kind() != rv2.Kind() {
+		d = newDiff(TypeMismatchDiff, path, rv1, rv2)
+		goto end
+	}
+
+	d, handled = o.customDiff(rv1, rv2, path)
+	if handled {
 		goto end
 	}
 
@@ -88,299 +137,579 @@ func (o *ObjectComparator) ReflectValuesDiff(rv1, rv2 *reflect.Value, format str
 		switch {
 		case !elem1.IsValid() && !elem2.IsValid():
 			// Do nothing
-		case !elem1.IsValid():
-			r := NewReflector(elem2)
-			diff = o.notEqualDiff(elem2.Type(), "nil", r.String())
-		case !elem2.IsValid():
-			r := NewReflector(elem1)
-			diff = o.notEqualDiff(elem1.Type(), r.String(), "nil")
+		case !elem1.IsValid(), !elem2.IsValid():
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		default:
-			//goland:noinspection GoSwitchMissingCasesForIotaConsts
-			switch rv1.Kind() {
-			case reflect.Pointer:
-				diff = o.ReflectValuesDiff(&elem1, &elem2, "*%s")
-			case reflect.Interface:
-				diff = o.ReflectValuesDiff(&elem1, &elem2, "any(%s)")
-			}
-		}
-		if diff != "" {
-			sb.WriteString(fmt.Sprintf(format, diff))
+			d = newParentDiff(path, rv1, rv2, o.diffValues(&elem1, &elem2, path))
 		}
 
 	case reflect.Struct:
-		diff := o.diffStruct(rv1, rv2)
-		if len(diff) > 0 {
-			f := "%s{%s}"
-			if opts.PrettyPrint.Value {
-				r := strings.Repeat(opts.LevelIndent.Value, o.level)
-				f = "%s{\n%s" + r + "}"
-			}
-			diff = fmt.Sprintf(f, rv1.Type().String(), diff)
-			sb.WriteString(fmt.Sprintf(format, diff))
-		}
+		d = newParentDiff(path, rv1, rv2, o.diffStruct(rv1, rv2, path)...)
 
 	case reflect.Slice, reflect.Array:
-		diff := o.diffElements(rv1, rv2)
-		if len(diff) > 0 {
-			//goland:noinspection GoSwitchMissingCasesForIotaConsts
-			switch rv1.Kind() {
-			case reflect.Slice:
-				diff = fmt.Sprintf("%s{%s}", rv1.Type().String(), diff)
-			case reflect.Array:
-				// TODO: Handle mismatches lengths
-				diff = fmt.Sprintf("%s%s", rv1.Type().String(), diff)
-			}
-			sb.WriteString(fmt.Sprintf(format, diff))
+		if d, handled = o.diffKeyedValues(rv1, rv2, path); handled {
+			break
+		}
+		if isBytesType(rv1.Type()) && !o.opts.SliceIndexMode && !o.isUnordered(rv1, path) {
+			d = o.diffBytes(rv1, rv2, path)
+			break
 		}
+		if o.isUnordered(rv1, path) {
+			d = newParentDiff(path, rv1, rv2, o.diffUnordered(rv1, rv2, path)...)
+			break
+		}
+		d = newParentDiff(path, rv1, rv2, o.diffElements(rv1, rv2, path)...)
 
 	case reflect.Map:
-		diff := o.diffMaps(rv1, rv2)
-		if len(diff) > 0 {
-			diff = fmt.Sprintf("map[%s]%s{%s}",
-				rv1.Type().Key(),
-				rv1.Type().Elem(),
-				diff,
-			)
-			sb.WriteString(fmt.Sprintf(format, diff))
-		}
+		d = newParentDiff(path, rv1, rv2, o.diffMaps(rv1, rv2, path)...)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if rv1.Int() != rv2.Int() {
-			diff := fmt.Sprintf(format, o.notEqualDiff(
-				rv1.Type(),
-				rv1.Int(),
-				rv2.Int(),
-			))
-			sb.WriteString(diff)
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		}
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
 		if rv1.Uint() != rv2.Uint() {
-			diff := fmt.Sprintf(format, o.notEqualDiff(
-				rv1.Type(),
-				rv1.Uint(),
-				rv2.Uint(),
-			))
-			sb.WriteString(diff)
+			d = newDiff(ChangedDiff, path, rv1, rv2)
+		}
+
+	case reflect.Chan:
+		if rv1.Pointer() != rv2.Pointer() {
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		}
 
 	case reflect.Func:
-		diff := o.diffFuncs(rv1, rv2)
-		if len(diff) > 0 {
-			diff = fmt.Sprintf("func(%s)%s{%s}",
-				o.funcParams(rv1),
-				o.funcReturns(rv1),
-				diff,
-			)
-			sb.WriteString(fmt.Sprintf(format, diff))
+		if o.funcsDiffer(rv1, rv2) {
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		}
 
 	case reflect.String:
 		if rv1.String() != rv2.String() {
-			diff := fmt.Sprintf(format, o.notEqualDiff(
-				rv1.Type(),
-				rv1.String(),
-				rv2.String(),
-			))
-			sb.WriteString(diff)
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		}
 
 	case reflect.Bool:
 		if rv1.Bool() != rv2.Bool() {
-			diff := fmt.Sprintf(format, o.notEqualDiff(
-				rv1.Type(),
-				rv1.String(),
-				rv2.String(),
-			))
-			sb.WriteString(diff)
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		}
 
 	case reflect.Float32, reflect.Float64:
-		if rv1.Float() != rv2.Float() {
-			diff := fmt.Sprintf(format, o.notEqualDiff(
-				rv1.Type(),
-				rv1.String(),
-				rv2.String(),
-			))
-			sb.WriteString(diff)
+		if !o.floatsEqual(rv1.Float(), rv2.Float(), rv1.Type().Bits()) {
+			d = newDiff(ChangedDiff, path, rv1, rv2)
+		}
+
+	case reflect.Complex64, reflect.Complex128:
+		if !o.complexesEqual(rv1.Complex(), rv2.Complex(), rv1.Type().Bits()) {
+			d = newDiff(ChangedDiff, path, rv1, rv2)
 		}
 
 	case reflect.UnsafePointer:
 		// Do nothing, we cannot compare anyway, nor should we
 
 	case reflect.Invalid:
-		if !reflect.DeepEqual(rv1, rv2) {
-			diff := fmt.Sprintf(format, o.notEqualDiff(nil, rv1, rv2))
-			sb.WriteString(diff)
-		}
+		// Both are invalid, so there is nothing to differ
 
 	default:
 		panicf("Unhandled kind '%s'", rv1.Kind())
 	}
-
-	if sb.Len() > 0 {
-		diff = sb.String()
-	}
-
 end:
-	if opts.PrettyPrint.Value && o.level == 0 && diff != "" {
-		diff = "\n" + diff
-	}
-	return diff
+	return d
 }
 
-func (o *ObjectComparator) diffStruct(rv1, rv2 *reflect.Value) string {
-	o.level++
-	diff := ""
-	opts := o.opts
-	sb := strings.Builder{}
-	for i := 0; i < rv1.NumField(); i++ {
+// diffStruct compares the fields of two structs of the same type, honoring the
+// fields' `diff` tags.
+func (o *ObjectComparator) diffStruct(rv1, rv2 *reflect.Value, path Path) (diffs []*Diff) {
+	tags := structTags(rv1.Type())
+	parentTag, parentDepth := o.tag, o.tagDepth
+	for i, tag := range tags {
+		if tag.skip {
+			continue
+		}
 		fld1 := rv1.Field(i)
 		fld2 := rv2.Field(i)
-		name := rv1.Type().Field(i).Name
-		diff = o.ReflectValuesDiff(&fld1, &fld2, fmt.Sprintf("%v:%s,", name, "%v"))
-		if diff == "" {
+		fldPath := path.withField(tag.name, i)
+		o.tag, o.tagDepth = tag, len(fldPath)
+		d := o.diffValues(&fld1, &fld2, fldPath)
+		if d == nil {
 			continue
 		}
-
-		if !opts.PrettyPrint.Value {
-			sb.WriteString(diff)
-			continue
+		if tag.redact {
+			d.Children = nil
+			d.Redacted = true
 		}
-		sb.WriteString(strings.Repeat(opts.LevelIndent.Value, o.level))
-		sb.WriteString(diff)
-		sb.WriteByte('\n')
+		diffs = append(diffs, d)
 	}
-	diff = sb.String()
-	o.level--
-	return diff
+	o.tag, o.tagDepth = parentTag, parentDepth
+	return diffs
 }
 
-func (o *ObjectComparator) indent() string {
-	return strings.Repeat(o.opts.LevelIndent.Value, o.level)
+// diffElements compares the elements of two slices or arrays by aligning them,
+// so that elements inserted or deleted are reported as such rather than as
+// changes to every element that follows. Changed and deleted elements are at
+// their index in rv1, and inserted elements at their index in rv2.
+//
+// Aligning compares elements deeply, so unless there are Ignore rules, which
+// can make elements equal that hash differently, elements are only compared if
+// their hashes are equal; see hashElement(). If more than maxAlignMisses
+// elements compared are found to differ, the elements are paired by index as
+// for SliceIndexMode instead.
+func (o *ObjectComparator) diffElements(rv1, rv2 *reflect.Value, path Path) (diffs []*Diff) {
+	var d *Diff
+	var h1, h2 []uint64
+	var misses int
+
+	if o.opts.SliceIndexMode {
+		return o.diffElementsByIndex(rv1, rv2, path)
+	}
+	if len(o.opts.Ignore) == 0 {
+		h1, h2 = o.hashElements(rv1, path), o.hashElements(rv2, path)
+	}
+	ops := alignElements(rv1.Len(), rv2.Len(), func(i, j int) bool {
+		if h1 != nil && h1[i] != h2[j] || misses > maxAlignMisses {
+			return false
+		}
+		idx1 := rv1.Index(i)
+		idx2 := rv2.Index(j)
+		if o.diffValues(&idx1, &idx2, path.withIndex(i)) != nil {
+			misses++
+			return false
+		}
+		return true
+	})
+	if misses > maxAlignMisses {
+		return o.diffElementsByIndex(rv1, rv2, path)
+	}
+	for _, op := range ops {
+		switch op.kind {
+		case changedElement:
+			idx1 := rv1.Index(op.want)
+			idx2 := rv2.Index(op.got)
+			d = o.diffValues(&idx1, &idx2, path.withIndex(op.want))
+			d.setGotIndex(op.got)
+		case deletedElement:
+			idx := rv1.Index(op.want)
+			d = o.missingDiff(MissingExpectedDiff, path.withIndex(op.want), &idx, nil)
+		case insertedElement:
+			idx := rv2.Index(op.got)
+			d = o.missingDiff(MissingActualDiff, path.withIndex(op.got), nil, &idx)
+		}
+		if d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
 }
 
-func (o *ObjectComparator) diffElements(rv1, rv2 *reflect.Value) (diff string) {
-	o.level++
-	opts := o.opts
-	sb := strings.Builder{}
+// diffElementsByIndex compares the elements of two slices or arrays at the
+// same index, for ObjectOpts.SliceIndexMode.
+func (o *ObjectComparator) diffElementsByIndex(rv1, rv2 *reflect.Value, path Path) (diffs []*Diff) {
+	var d *Diff
 	cnt := max(rv1.Len(), rv2.Len())
 	for i := 0; i < cnt; i++ {
 		switch {
 		case i >= rv1.Len():
 			idx := rv2.Index(i)
-			diff = o.compare(
-				"<missing>",
-				fmt.Sprintf("%v", NewReflector(&idx).String()),
-				"%s,",
-			)
+			d = o.missingDiff(MissingActualDiff, path.withIndex(i), nil, &idx)
 		case i >= rv2.Len():
 			idx := rv1.Index(i)
-			diff = o.compare(
-				fmt.Sprintf("%v", NewReflector(&idx).String()),
-				"<missing>",
-				"%s,",
-			)
+			d = o.missingDiff(MissingExpectedDiff, path.withIndex(i), &idx, nil)
 		default:
 			idx1 := rv1.Index(i)
 			idx2 := rv2.Index(i)
-			diff = o.ReflectValuesDiff(&idx1, &idx2, "%s,")
+			d = o.diffValues(&idx1, &idx2, path.withIndex(i))
+			d.setGotIndex(i)
 		}
-		if diff != "" {
-			f := "[%d]%s"
-			if opts.PrettyPrint.Value {
-				f = "\n" + o.indent() + f
-			}
-			sb.WriteString(fmt.Sprintf(f, i, diff))
+		if d != nil {
+			diffs = append(diffs, d)
 		}
 	}
-	o.level--
-	if diff != "" && opts.PrettyPrint.Value {
-		sb.WriteByte('\n')
-		sb.WriteString(o.indent())
-	}
-	diff = sb.String()
-	return diff
+	return diffs
 }
 
-func (o *ObjectComparator) diffMaps(rv1, rv2 *reflect.Value) (diff string) {
-	sb := strings.Builder{}
+func (o *ObjectComparator) diffMaps(rv1, rv2 *reflect.Value, path Path) (diffs []*Diff) {
 	tkr1 := NewTrackerWithKeys(rv1)
 	tkr2 := NewTrackerWithKeys(rv2)
 
 	for _, key := range tkr1.SortedKeys {
 		seen, id := tkr2.HaveSeen(&key)
 		if !seen {
-			sb.WriteString(fmt.Sprintf("%v:<missing:expected>,", key))
+			val := rv1.MapIndex(key)
+			d := o.missingDiff(MissingExpectedDiff, path.withKey(key), &val, nil)
+			if d != nil {
+				diffs = append(diffs, d)
+			}
 			continue
 		}
 		tkr2.Delete(id)
 		key1 := rv1.MapIndex(key)
 		key2 := rv2.MapIndex(key)
-		diff = o.ReflectValuesDiff(&key1, &key2, fmt.Sprintf("%v:%s,", key, "%v"))
-		if diff != "" {
-			sb.WriteString(diff)
+		d := o.diffValues(&key1, &key2, path.withKey(key))
+		if d != nil {
+			diffs = append(diffs, d)
 		}
 	}
 	for _, key := range tkr2.SortedKeys {
 		seen, _ := tkr1.HaveSeen(&key)
 		if !seen {
-			sb.WriteString(fmt.Sprintf("%v:<missing:actual>,", key))
+			val := rv2.MapIndex(key)
+			d := o.missingDiff(MissingActualDiff, path.withKey(key), nil, &val)
+			if d != nil {
+				diffs = append(diffs, d)
+			}
 		}
 	}
-	diff = sb.String()
-	return diff
+	return diffs
 }
 
-func (o *ObjectComparator) checkValid(rv1, rv2 *reflect.Value, sb strings.Builder) bool {
-	if rv1.IsValid() != rv2.IsValid() {
-		sb.WriteString(o.notEqualDiff(reflect.TypeOf(nil),
-			fmt.Sprintf("Valid:%t", rv1.IsValid()),
-			fmt.Sprintf("Valid:%t", rv2.IsValid()),
-		))
-		return false
+// missingDiff returns a Diff of kind for a value only found on one side, or nil
+// if the value is ignored.
+func (o *ObjectComparator) missingDiff(kind DiffKind, path Path, rv1, rv2 *reflect.Value) (d *Diff) {
+	if o.ignored(path, rv1, rv2) {
+		goto end
 	}
-	return true
+	d = newDiff(kind, path, rv1, rv2)
+end:
+	return d
 }
 
-func (o *ObjectComparator) checkKind(rv1, rv2 *reflect.Value, sb strings.Builder) bool {
-	if rv1.Kind() != rv2.Kind() {
-		sb.WriteString(o.notEqualDiff(rv1.Type(),
-			fmt.Sprintf("Kind:%s", rv1.Kind().String()),
-			fmt.Sprintf("Kind:%s", rv2.Kind().String()),
+// ignored returns true if any of the Ignore rules match the value at path,
+// being rv1 or, if rv1 is missing or invalid, rv2.
+func (o *ObjectComparator) ignored(path Path, rv1, rv2 *reflect.Value) (ignore bool) {
+	var rv reflect.Value
+
+	if len(o.opts.Ignore) == 0 {
+		goto end
+	}
+	switch {
+	case rv1 != nil && rv1.IsValid():
+		rv = *rv1
+	case rv2 != nil:
+		rv = *rv2
+	}
+	for _, rule := range o.opts.Ignore {
+		if rule(path, rv) {
+			ignore = true
+			break
+		}
+	}
+end:
+	return ignore
+}
+
+// renderDiff returns the string form of d, formatted with format.
+func (o *ObjectComparator) renderDiff(d *Diff, format string) (diff string) {
+	var rv1, rv2 reflect.Value
+	opts := o.opts
+
+	if d == nil {
+		goto end
+	}
+	rv1, rv2 = d.Want, d.Got
+
+	if d.Redacted {
+		diff = fmt.Sprintf(format, fmt.Sprintf("(%s!=%s)",
+			opts.style.Want(Redacted),
+			opts.style.Got(Redacted),
+		))
+		goto end
+	}
+
+	if d.Kind == ChangedDiff && d.IsLeaf() && isBytesType(rv1.Type()) {
+		diff = fmt.Sprintf(format, o.renderBytes(&rv1, &rv2))
+		goto end
+	}
+
+	if isOpaqueLeaf(d) {
+		diff = fmt.Sprintf(format, o.notEqualDiff(
+			rv1.Type(),
+			opaqueString(rv1),
+			opaqueString(rv2),
+		))
+		goto end
+	}
+
+	switch d.Kind {
+	case InvalidDiff:
+		diff = "<invalid>"
+		goto end
+	case TypeMismatchDiff:
+		diff = fmt.Sprintf("<type-mismatch>:%s", o.notEqualDiff(
+			rv1.Type(),
+			redactedString(rv1),
+			redactedString(rv2),
 		))
+		goto end
+	}
+
+	switch rv1.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		elem1 := rv1.Elem()
+		elem2 := rv2.Elem()
+		switch {
+		case !elem1.IsValid():
+			diff = o.notEqualDiff(elem2.Type(), "nil", redactedString(elem2))
+		case !elem2.IsValid():
+			diff = o.notEqualDiff(elem1.Type(), redactedString(elem1), "nil")
+		default:
+			//goland:noinspection GoSwitchMissingCasesForIotaConsts
+			switch rv1.Kind() {
+			case reflect.Pointer:
+				diff = o.renderDiff(d.Children[0], "*%s")
+			case reflect.Interface:
+				diff = o.renderDiff(d.Children[0], "any(%s)")
+			}
+		}
+		diff = fmt.Sprintf(format, diff)
+
+	case reflect.Struct:
+		diff = o.renderStruct(d)
+		f := "%s{%s}"
+		if opts.PrettyPrint.Value {
+			f = "%s{\n%s" + o.indent() + "}"
+		}
+		diff = fmt.Sprintf(f, rv1.Type().String(), diff)
+		diff = fmt.Sprintf(format, diff)
+
+	case reflect.Slice, reflect.Array:
+		diff = o.renderElements(d)
+		//goland:noinspection GoSwitchMissingCasesForIotaConsts
+		switch rv1.Kind() {
+		case reflect.Slice:
+			diff = fmt.Sprintf("%s{%s}", rv1.Type().String(), diff)
+		case reflect.Array:
+			// TODO: Handle mismatches lengths
+			diff = fmt.Sprintf("%s%s", rv1.Type().String(), diff)
+		}
+		diff = fmt.Sprintf(format, diff)
+
+	case reflect.Map:
+		diff = fmt.Sprintf("map[%s]%s{%s}",
+			rv1.Type().Key(),
+			rv1.Type().Elem(),
+			o.renderMap(d),
+		)
+		diff = fmt.Sprintf(format, diff)
+
+	case reflect.Func:
+		diff = fmt.Sprintf("func(%s)%s{%s}",
+			o.funcParams(&rv1),
+			o.funcReturns(&rv1),
+			o.renderFuncs(&rv1, &rv2),
+		)
+		diff = fmt.Sprintf(format, diff)
+
+	case reflect.Complex64, reflect.Complex128:
+		diff = fmt.Sprintf(format, o.renderComplex(&rv1, &rv2))
+
+	case reflect.String:
+		if o.diffsStrings(rv1.String(), rv2.String()) {
+			diff = fmt.Sprintf(format, o.renderString(rv1.String(), rv2.String()))
+			break
+		}
+		diff = fmt.Sprintf(format, o.notEqualDiff(rv1.Type(), rv1.String(), rv2.String()))
+
+	default:
+		diff = fmt.Sprintf(format, o.notEqualDiff(
+			rv1.Type(),
+			scalarValue(rv1),
+			scalarValue(rv2),
+		))
+	}
+end:
+	return diff
+}
+
+// scalarValue returns the value of rv as displayed in a `(want!=got)` diff.
+func scalarValue(rv reflect.Value) (v any) {
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		v = rv.Uint()
+	case reflect.String:
+		v = rv.String()
+	case reflect.Bool:
+		v = rv.Bool()
+	case reflect.Float32, reflect.Float64:
+		v = rv.Float()
+	case reflect.Chan:
+		v = NewReflector(rv).String()
+	default:
+		v = rv
+	}
+	return v
+}
+
+// renderComplex renders the differing real and imaginary parts of two complex
+// numbers, e.g. `complex128{imag:(1!=2),}`.
+func (o *ObjectComparator) renderComplex(rv1, rv2 *reflect.Value) string {
+	c1, c2 := rv1.Complex(), rv2.Complex()
+	bits := rv1.Type().Bits() / 2
+	rt := reflect.TypeOf(float64(0))
+	if bits == 32 {
+		rt = reflect.TypeOf(float32(0))
+	}
+	sb := strings.Builder{}
+	sb.WriteString(rv1.Type().String())
+	sb.WriteByte('{')
+	if !o.floatsEqual(real(c1), real(c2), bits) {
+		sb.WriteString("real:")
+		sb.WriteString(o.notEqualDiff(rt, real(c1), real(c2)))
+		sb.WriteByte(',')
+	}
+	if !o.floatsEqual(imag(c1), imag(c2), bits) {
+		sb.WriteString("imag:")
+		sb.WriteString(o.notEqualDiff(rt, imag(c1), imag(c2)))
+		sb.WriteByte(',')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+// diffsStrings returns true if s1 and s2 are to be rendered with
+// CompareStrings rather than whole.
+func (o *ObjectComparator) diffsStrings(s1, s2 string) bool {
+	opts := o.opts
+	if !opts.DiffStrings.Value {
 		return false
 	}
-	return true
+	n := max(utf8.RuneCountInString(s1), utf8.RuneCountInString(s2))
+	return n >= opts.DiffStringsMinLen.Value
+}
+
+// renderString renders the differences between two strings with
+// CompareStrings, e.g. `"Hello <(World/Gopher)>!"`.
+func (o *ObjectComparator) renderString(s1, s2 string) string {
+	return `"` + CompareStrings(s1, s2, o.stringOpts()) + `"`
+}
+
+// stringOpts returns a copy of ObjectOpts.StringOpts to pass to CompareStrings,
+// styled as for the object unless it sets its own Color.
+func (o *ObjectComparator) stringOpts() *StringOpts {
+	var opts StringOpts
+	if o.opts.StringOpts != nil {
+		opts = *o.opts.StringOpts
+	}
+	if opts.Color == ColorNever {
+		opts.Color = ColorAlways
+		opts.Styler = o.opts.style
+	}
+	return &opts
+}
+
+func (o *ObjectComparator) renderStruct(d *Diff) string {
+	o.level++
+	opts := o.opts
+	sb := strings.Builder{}
+	for _, child := range d.Children {
+		name := child.Path.Last().Name
+		diff := o.renderDiff(child, fmt.Sprintf("%v:%s,", name, "%v"))
+		if !opts.PrettyPrint.Value {
+			sb.WriteString(diff)
+			continue
+		}
+		sb.WriteString(o.indent())
+		sb.WriteString(diff)
+		sb.WriteByte('\n')
+	}
+	o.level--
+	return sb.String()
+}
+
+func (o *ObjectComparator) indent() string {
+	return strings.Repeat(o.opts.LevelIndent.Value, o.level)
+}
+
+func (o *ObjectComparator) renderElements(d *Diff) string {
+	var diff string
+	o.level++
+	opts := o.opts
+	sb := strings.Builder{}
+	for _, child := range d.Children {
+		switch {
+		case child.Kind == MissingActualDiff && !opts.SliceIndexMode:
+			diff = opts.style.Got(fmt.Sprintf("<inserted:%s>", redactedString(child.Got))) + ","
+		case child.Kind == MissingExpectedDiff && !opts.SliceIndexMode:
+			diff = opts.style.Want(fmt.Sprintf("<deleted:%s>", redactedString(child.Want))) + ","
+		case child.Kind == MissingActualDiff:
+			diff = o.notEqualDiff(reflect.TypeOf(""),
+				"<missing>",
+				redactedString(child.Got),
+			) + ","
+		case child.Kind == MissingExpectedDiff:
+			diff = o.notEqualDiff(reflect.TypeOf(""),
+				redactedString(child.Want),
+				"<missing>",
+			) + ","
+		default:
+			diff = o.renderDiff(child, "%s,")
+		}
+		f := "%s%s"
+		if opts.PrettyPrint.Value {
+			f = "\n" + o.indent() + f
+		}
+		sb.WriteString(fmt.Sprintf(f, child.Path.Last(), diff))
+	}
+	o.level--
+	if opts.PrettyPrint.Value {
+		sb.WriteByte('\n')
+		sb.WriteString(o.indent())
+	}
+	return sb.String()
+}
+
+func (o *ObjectComparator) renderMap(d *Diff) string {
+	sb := strings.Builder{}
+	for _, child := range d.Children {
+		key := child.Path.Last().Key
+		switch child.Kind {
+		case MissingExpectedDiff:
+			sb.WriteString(fmt.Sprintf("%v:%s,", key, o.opts.style.Want("<missing:expected>")))
+		case MissingActualDiff:
+			sb.WriteString(fmt.Sprintf("%v:%s,", key, o.opts.style.Got("<missing:actual>")))
+		default:
+			sb.WriteString(o.renderDiff(child, fmt.Sprintf("%v:%s,", key, "%v")))
+		}
+	}
+	return sb.String()
 }
 
 func (o *ObjectComparator) notEqualDiff(rt reflect.Type, v1, v2 any) (diff string) {
+	var s1, s2 string
 	opts := o.opts
 	if opts.FormatFunc == nil {
-		diff = fmt.Sprintf("(%v!=%v)", v1, v2)
+		s1 = fmt.Sprintf("%v", v1)
+		s2 = fmt.Sprintf("%v", v2)
 		goto end
 	}
-	diff = fmt.Sprintf("(%s!=%s)",
-		opts.FormatFunc(rt, v1),
-		opts.FormatFunc(rt, v2),
-	)
+	s1 = opts.FormatFunc(rt, v1)
+	s2 = opts.FormatFunc(rt, v2)
 end:
-	return diff
+	return fmt.Sprintf("(%s!=%s)", opts.style.Want(s1), opts.style.Got(s2))
 }
 
-func (o *ObjectComparator) diffFuncs(rv1, rv2 *reflect.Value) (diff string) {
+func (o *ObjectComparator) funcsDiffer(rv1, rv2 *reflect.Value) (differ bool) {
 	if rv1.IsNil() && rv2.IsNil() {
 		goto end
 	}
+	if rv1.IsNil() || rv2.IsNil() {
+		differ = true
+		goto end
+	}
+	if !o.opts.CompareFuncs {
+		goto end
+	}
+end:
+	return differ
+}
+
+func (o *ObjectComparator) renderFuncs(rv1, rv2 *reflect.Value) (diff string) {
 	if rv1.IsNil() {
 		diff = fmt.Sprintf("(nil!=func(%s)%s)", o.funcParams(rv2), o.funcReturns(rv2))
 		goto end
 	}
 	if rv2.IsNil() {
-		diff = fmt.Sprintf("(func(%s)%s)!=nil)", o.funcParams(rv1), o.funcReturns(rv1))
-		goto end
-	}
-	if !o.opts.CompareFuncs {
+		diff = fmt.Sprintf("(func(%s)%s!=nil)", o.funcParams(rv1), o.funcReturns(rv1))
 		goto end
 	}
 end:
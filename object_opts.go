@@ -10,6 +10,75 @@ type ObjectOpts struct {
 	PrettyPrint  *BoolValue
 	CompareFuncs bool
 	FormatFunc   func(reflect.Type, any) string
+	// FloatAbsEpsilon is the largest absolute difference allowed between two
+	// floats, or between the real or imaginary parts of two complex numbers,
+	// for them to be considered equal.
+	FloatAbsEpsilon float64
+	// FloatRelEpsilon is the largest difference allowed between two floats
+	// relative to the larger of their magnitudes, e.g. 1e-9.
+	FloatRelEpsilon float64
+	// FloatMaxULPs is the largest number of representable values allowed
+	// between two floats, i.e. their distance in units in the last place.
+	FloatMaxULPs uint64
+	// NaNEqual considers two NaN floats to be equal.
+	NaNEqual bool
+	// Comparers customizes how values of specific types are compared, e.g.
+	// Comparer(func(a, b time.Time) bool {...}). A later comparer for a type
+	// replaces an earlier one.
+	Comparers []CustomComparer
+	// IgnoreEqualMethods disables comparing values of types with an
+	// `Equal(T) bool` method, such as time.Time or net.IP, using that method.
+	IgnoreEqualMethods bool
+	// SliceIndexMode compares slice and array elements at the same index, as
+	// `[1](want!=got)`, rather than aligning them to find the elements inserted
+	// and deleted, as `[0]<inserted:got>` and `[3]<deleted:want>`.
+	SliceIndexMode bool
+	// UnorderedSlices compares every slice and array as a multiset, ignoring
+	// the order of its elements.
+	UnorderedSlices bool
+	// UnorderedPaths lists path expressions, as for IgnorePath(), of slices and
+	// arrays to compare ignoring the order of their elements.
+	UnorderedPaths []string
+	// UnorderedTypes lists values of the slice or array types, or of the element
+	// types, to compare ignoring the order of elements, e.g. []User(nil).
+	UnorderedTypes []any
+	// SliceKeys lists keys by which to match the elements of slices and arrays,
+	// e.g. KeyField[User]("ID"), rather than by their position.
+	SliceKeys []SliceKey
+	// DiffStrings renders differing strings with CompareStrings, e.g.
+	// `Body:"Hello <(World/Gopher)>!"`, rather than whole, as
+	// `Body:(Hello World!!=Hello Gopher!)`.
+	DiffStrings *BoolValue
+	// DiffStringsMinLen is the length in runes the longer of two differing
+	// strings must reach for DiffStrings to apply; shorter strings are rendered
+	// whole.
+	DiffStringsMinLen *IntValue
+	// StringOpts configures CompareStrings for DiffStrings and for byte slices
+	// that hold text. Differences are styled as for the object unless Color is
+	// set in StringOpts.
+	StringOpts *StringOpts
+	// SideBySide renders the want and got values in two columns, pretty printed
+	// with one field, element or map entry per line indented by LevelIndent,
+	// with a gutter marking the lines changed, deleted and inserted. StringOpts
+	// can set ContextLines to elide unchanged lines.
+	SideBySide *BoolValue
+	// Width is the width in columns of SideBySide output. Lines too long for
+	// their column are wrapped.
+	Width *IntValue
+	// Ignore lists rules for values to leave out of the comparison, e.g.
+	// IgnorePath("Users[*].CreatedAt") or IgnoreType(time.Time{}).
+	Ignore []IgnoreRule
+	// Color enables styling the want and got sides of each difference using
+	// Styler.
+	Color ColorMode
+	// Styler styles the output when Color is enabled; ANSIColors if nil.
+	Styler Styler
+
+	style          Styler
+	comparers      map[reflect.Type]CustomComparer
+	unorderedPaths []pathPattern
+	unorderedTypes map[reflect.Type]struct{}
+	sliceKeys      map[reflect.Type]SliceKey
 }
 
 func (opts *ObjectOpts) SetDefaults() {
@@ -22,4 +91,37 @@ func (opts *ObjectOpts) SetDefaults() {
 	if opts.PrettyPrint == nil {
 		opts.PrettyPrint = Bool(false)
 	}
+	if opts.SideBySide == nil {
+		opts.SideBySide = Bool(false)
+	}
+	if opts.Width == nil {
+		opts.Width = Int(SideBySideWidth)
+	}
+	if opts.DiffStrings == nil {
+		opts.DiffStrings = Bool(false)
+	}
+	if opts.DiffStringsMinLen == nil {
+		opts.DiffStringsMinLen = Int(0)
+	}
+	opts.style = resolveStyler(opts.Color, opts.Styler)
+	opts.comparers = make(map[reflect.Type]CustomComparer, len(opts.Comparers))
+	for _, c := range opts.Comparers {
+		opts.comparers[c.rt] = c
+	}
+	opts.sliceKeys = make(map[reflect.Type]SliceKey, len(opts.SliceKeys))
+	for _, sk := range opts.SliceKeys {
+		opts.sliceKeys[sk.rt] = sk
+	}
+	opts.unorderedPaths = make([]pathPattern, len(opts.UnorderedPaths))
+	for i, expr := range opts.UnorderedPaths {
+		opts.unorderedPaths[i] = parsePathPattern(expr)
+	}
+	opts.unorderedTypes = make(map[reflect.Type]struct{}, len(opts.UnorderedTypes))
+	for _, v := range opts.UnorderedTypes {
+		rt, ok := v.(reflect.Type)
+		if !ok {
+			rt = reflect.TypeOf(v)
+		}
+		opts.unorderedTypes[rt] = struct{}{}
+	}
 }
@@ -0,0 +1,132 @@
+package diffator
+
+import (
+	"cmp"
+	"reflect"
+)
+
+// maxOrderDepth limits how deeply CompareReflectValues follows pointers, so
+// that recursive values are still ordered, if only by address.
+const maxOrderDepth = 16
+
+// CompareReflectValues returns -1, 0 or +1 as a is less than, equal to or
+// greater than b in a total ordering of reflect values that is the same from
+// one run to the next wherever it can be:
+//
+//   - invalid values come first, and values of different types are ordered by
+//     type name, then kind,
+//   - booleans, numbers and strings are ordered by value, with NaN before any
+//     other float and complex numbers by real then imaginary part,
+//   - structs, arrays and slices are ordered field by field or element by
+//     element, then by length,
+//   - nil pointers, interfaces, maps, slices, channels and funcs come before
+//     non-nil ones, and pointers and interfaces are otherwise ordered by the
+//     values they hold, and
+//   - channels, funcs and unsafe pointers, as well as pointers nested too deeply,
+//     are ordered by address.
+func CompareReflectValues(a, b reflect.Value) int {
+	return compareReflectValues(a, b, 0)
+}
+
+func compareReflectValues(a, b reflect.Value, depth int) (c int) {
+	var rt reflect.Type
+
+	switch {
+	case !a.IsValid() || !b.IsValid():
+		c = compareBools(a.IsValid(), b.IsValid())
+		goto end
+	case a.Type() != b.Type():
+		c = compareTypes(a.Type(), b.Type())
+		goto end
+	}
+	rt = a.Type()
+
+	switch a.Kind() {
+	case reflect.Bool:
+		c = compareBools(a.Bool(), b.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c = cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		c = cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		c = cmp.Compare(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		c = cmp.Compare(real(a.Complex()), real(b.Complex()))
+		if c == 0 {
+			c = cmp.Compare(imag(a.Complex()), imag(b.Complex()))
+		}
+	case reflect.String:
+		c = cmp.Compare(a.String(), b.String())
+	case reflect.Struct:
+		for i := 0; i < rt.NumField() && c == 0; i++ {
+			c = compareReflectValues(a.Field(i), b.Field(i), depth)
+		}
+	case reflect.Array:
+		for i := 0; i < a.Len() && c == 0; i++ {
+			c = compareReflectValues(a.Index(i), b.Index(i), depth)
+		}
+	case reflect.Slice:
+		if a.IsNil() || b.IsNil() {
+			c = compareBools(!a.IsNil(), !b.IsNil())
+			break
+		}
+		for i := 0; i < min(a.Len(), b.Len()) && c == 0; i++ {
+			c = compareReflectValues(a.Index(i), b.Index(i), depth)
+		}
+		if c == 0 {
+			c = cmp.Compare(a.Len(), b.Len())
+		}
+	case reflect.Pointer, reflect.Interface:
+		switch {
+		case a.IsNil() || b.IsNil():
+			c = compareBools(!a.IsNil(), !b.IsNil())
+		case a.Kind() == reflect.Pointer && a.Pointer() == b.Pointer():
+			// Same pointer, so same value
+		case depth < maxOrderDepth:
+			c = compareReflectValues(a.Elem(), b.Elem(), depth+1)
+		default:
+			c = cmp.Compare(a.Pointer(), b.Pointer())
+		}
+	case reflect.Map:
+		if a.IsNil() || b.IsNil() {
+			c = compareBools(!a.IsNil(), !b.IsNil())
+			break
+		}
+		c = cmp.Compare(a.Len(), b.Len())
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		c = compareBools(!a.IsNil(), !b.IsNil())
+		if c == 0 {
+			c = cmp.Compare(a.Pointer(), b.Pointer())
+		}
+	}
+end:
+	return c
+}
+
+// compareTypes orders types by name, then package path, then kind, so that
+// the values held by interfaces of mixed dynamic types are grouped by type.
+func compareTypes(a, b reflect.Type) (c int) {
+	c = cmp.Compare(a.String(), b.String())
+	if c != 0 {
+		goto end
+	}
+	c = cmp.Compare(a.PkgPath(), b.PkgPath())
+	if c != 0 {
+		goto end
+	}
+	c = cmp.Compare(a.Kind(), b.Kind())
+end:
+	return c
+}
+
+// compareBools orders false before true.
+func compareBools(a, b bool) (c int) {
+	switch {
+	case a == b:
+	case b:
+		c = -1
+	default:
+		c = 1
+	}
+	return c
+}
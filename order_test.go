@@ -0,0 +1,122 @@
+package diffator_test
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type Point struct {
+	X, Y int
+}
+
+func TestSortedMapKeys(t *testing.T) {
+	p1, p2, p3 := &Point{2, 1}, &Point{1, 5}, &Point{1, 2}
+	tests := []struct {
+		name     string
+		m        any
+		wantKeys []any
+	}{
+		{
+			name:     "int",
+			m:        map[int]string{10: "", -3: "", 2: "", 100: "", 0: ""},
+			wantKeys: []any{-3, 0, 2, 10, 100},
+		},
+		{
+			name:     "uint8",
+			m:        map[uint8]bool{200: true, 3: false, 20: true},
+			wantKeys: []any{uint8(3), uint8(20), uint8(200)},
+		},
+		{
+			name:     "float",
+			m:        map[float64]int{2.5: 0, -1: 0, math.Inf(1): 0, 0.1: 0},
+			wantKeys: []any{-1.0, 0.1, 2.5, math.Inf(1)},
+		},
+		{
+			name:     "bool",
+			m:        map[bool]int{true: 1, false: 0},
+			wantKeys: []any{false, true},
+		},
+		{
+			name:     "string",
+			m:        map[string]int{"b": 0, "B": 0, "a": 0, "": 0},
+			wantKeys: []any{"", "B", "a", "b"},
+		},
+		{
+			name:     "struct",
+			m:        map[Point]int{{2, 1}: 0, {1, 5}: 0, {1, 2}: 0},
+			wantKeys: []any{Point{1, 2}, Point{1, 5}, Point{2, 1}},
+		},
+		{
+			name:     "array",
+			m:        map[[2]string]int{{"b", "a"}: 0, {"a", "b"}: 0, {"a", "a"}: 0},
+			wantKeys: []any{[2]string{"a", "a"}, [2]string{"a", "b"}, [2]string{"b", "a"}},
+		},
+		{
+			name:     "pointer",
+			m:        map[*Point]int{p1: 0, p2: 0, p3: 0, nil: 0},
+			wantKeys: []any{(*Point)(nil), p3, p2, p1},
+		},
+		{
+			name:     "mixed interface",
+			m:        map[any]int{"b": 0, 2: 0, "a": 0, 1: 0, 1.5: 0, true: 0, Point{1, 1}: 0, nil: 0},
+			wantKeys: []any{nil, true, Point{1, 1}, 1.5, 1, 2, "a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Map iteration order is random so repeat to catch any instability
+			for i := 0; i < 10; i++ {
+				var keys []any
+				for _, key := range diffator.SortedMapKeys(tt.m) {
+					keys = append(keys, key.Interface())
+				}
+				if !assert.Equal(t, tt.wantKeys, keys) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestCompareReflectValues(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		a, b any
+		want int
+	}{
+		{a: 1, b: 2, want: -1},
+		{a: 2, b: 2, want: 0},
+		{a: -1.5, b: -2.5, want: 1},
+		{a: nan, b: 0.0, want: -1},
+		{a: nan, b: nan, want: 0},
+		{a: complex(1, 2), b: complex(1, 1), want: 1},
+		{a: "abc", b: "abd", want: -1},
+		{a: []int{1, 2}, b: []int{1, 2, 0}, want: -1},
+		{a: []int{1, 3}, b: []int{1, 2, 0}, want: 1},
+		{a: []int(nil), b: []int{}, want: -1},
+		{a: Point{1, 2}, b: Point{1, 2}, want: 0},
+		{a: int8(5), b: 5, want: 1},
+		{a: nil, b: 0, want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v:%v", tt.a, tt.b), func(t *testing.T) {
+			a, b := reflect.ValueOf(tt.a), reflect.ValueOf(tt.b)
+			assert.Equal(t, tt.want, diffator.CompareReflectValues(a, b))
+			assert.Equal(t, -tt.want, diffator.CompareReflectValues(b, a))
+		})
+	}
+}
+
+func TestCompareObjectsMapKeyOrder(t *testing.T) {
+	v1 := map[int]string{3: "c", 1: "a", 20: "t", 2: "b"}
+	v2 := map[int]string{3: "C", 1: "A", 20: "T", 2: "B"}
+	want := "map[int]string{1:(a!=A),2:(b!=B),3:(c!=C),20:(t!=T),}"
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, want, diffator.CompareObjects(v1, v2, nil))
+	}
+}
@@ -0,0 +1,542 @@
+package diffator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ErrPatchConflict is wrapped by the errors Patch() and Unpatch() return when
+// the target does not match the side of the Diff being patched from.
+var ErrPatchConflict = errors.New("patch conflict")
+
+// PatchConflictError reports where and how a target did not match the Diff
+// applied to it.
+type PatchConflictError struct {
+	Path   Path
+	Detail string
+}
+
+func (e *PatchConflictError) Error() string {
+	path := e.Path.String()
+	if path == "" {
+		path = "<root>"
+	}
+	return fmt.Sprintf("%s at %s: %s", ErrPatchConflict, path, e.Detail)
+}
+
+func (e *PatchConflictError) Unwrap() error {
+	return ErrPatchConflict
+}
+
+// Patch applies d, as returned by CompareObjectsResult(want, got), to the value
+// target points to, which must match want wherever d has a difference. Struct
+// fields, including unexported ones, are set to their got values, slice
+// elements and map entries are inserted and removed, and so on.
+//
+// If target does not match want, as compared with the ObjectOpts d was computed
+// with, Patch returns a *PatchConflictError and leaves target unchanged. Containers along the paths of differences are copied
+// rather than modified in place, so that d still describes the values it was
+// computed from and can be passed to Unpatch().
+func Patch(target any, d *Diff) (err error) {
+	var rv, work reflect.Value
+	var p *patcher
+
+	if d == nil {
+		goto end
+	}
+	rv = reflect.ValueOf(target)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		err = fmt.Errorf("diffator.Patch() target must be a non-nil pointer, not %T", target)
+		goto end
+	}
+	if d.WantType() == rv.Type() && d.Kind == ChangedDiff && len(d.Children) == 1 {
+		// d compares pointers to values like the target's, so apply what it
+		// found for the values pointed to
+		d = d.Children[0]
+	}
+	rv = rv.Elem()
+	work = reflect.New(rv.Type()).Elem()
+	work.Set(rv)
+	p = newPatcher(d.opts)
+	err = p.apply(work, d)
+	if err != nil {
+		goto end
+	}
+	rv.Set(work)
+end:
+	return err
+}
+
+// Unpatch reverts a Patch() of d to the value target points to, which must
+// match the got side of d wherever d has a difference.
+func Unpatch(target any, d *Diff) error {
+	return Patch(target, Reverse(d))
+}
+
+// Reverse returns a copy of d describing the differences from got to want, so
+// its Want and Got are swapped, values missing from got are instead missing
+// from want and vice versa, and slice elements are at their index in got.
+func Reverse(d *Diff) *Diff {
+	if d == nil {
+		return nil
+	}
+	return reverseDiff(d, reversedStep(d, d.Path))
+}
+
+func reverseDiff(d *Diff, path Path) *Diff {
+	r := &Diff{
+		Kind:     d.Kind,
+		Path:     path,
+		Want:     d.Got,
+		Got:      d.Want,
+		Redacted: d.Redacted,
+		opts:     d.opts,
+	}
+	switch d.Kind {
+	case MissingExpectedDiff:
+		r.Kind = MissingActualDiff
+	case MissingActualDiff:
+		r.Kind = MissingExpectedDiff
+	}
+	if len(path) > 0 {
+		r.gotIndex = d.Path.Last().Index
+	}
+	for _, child := range d.Children {
+		childPath := path
+		if len(child.Path) > len(d.Path) {
+			childPath = reversedStep(child, path)
+		}
+		r.Children = append(r.Children, reverseDiff(child, childPath))
+	}
+	return r
+}
+
+// reversedStep returns parent with the last step of d's path appended, using
+// the index in got for a changed element of a slice or array.
+func reversedStep(d *Diff, parent Path) Path {
+	if len(d.Path) == 0 {
+		return parent
+	}
+	step := d.Path.Last()
+	isElement := step.Kind == IndexStep || step.Kind == ElementKeyStep
+	if isElement && d.Kind != MissingExpectedDiff && d.Kind != MissingActualDiff {
+		step.Index = d.gotIndex
+	}
+	if len(parent) == len(d.Path) {
+		parent = parent[:len(parent)-1]
+	}
+	return parent.with(step)
+}
+
+// patcher applies a Diff to a value.
+type patcher struct {
+	*ObjectComparator
+}
+
+func newPatcher(opts *ObjectOpts) *patcher {
+	return &patcher{ObjectComparator: NewObjectComparator(nil, nil, opts)}
+}
+
+// apply applies d to rv, a settable value at d.Path that is not shared with
+// the values d was computed from.
+func (p *patcher) apply(rv reflect.Value, d *Diff) (err error) {
+	switch {
+	case d.IsLeaf():
+		err = p.replace(rv, d)
+	case d.Want.Kind() == reflect.Pointer:
+		err = p.applyPointer(rv, d)
+	case d.Want.Kind() == reflect.Interface:
+		err = p.applyInterface(rv, d)
+	case d.Want.Kind() == reflect.Struct:
+		err = p.applyStruct(rv, d)
+	case d.Want.Kind() == reflect.Map:
+		err = p.applyMap(rv, d)
+	case d.Want.Kind() == reflect.Slice, d.Want.Kind() == reflect.Array:
+		err = p.applyElements(rv, d)
+	default:
+		err = p.replace(rv, d)
+	}
+	return err
+}
+
+// replace sets rv to a copy of d.Got if rv matches d.Want.
+func (p *patcher) replace(rv reflect.Value, d *Diff) (err error) {
+	err = p.check(rv, d.Want, d.Path)
+	if err != nil {
+		goto end
+	}
+	if !d.Got.IsValid() {
+		rv.Set(reflect.Zero(rv.Type()))
+		goto end
+	}
+	if !d.Got.Type().AssignableTo(rv.Type()) {
+		err = &PatchConflictError{
+			Path:   d.Path,
+			Detail: fmt.Sprintf("cannot assign %s to %s", d.Got.Type(), rv.Type()),
+		}
+		goto end
+	}
+	rv.Set(copyOf(d.Got))
+end:
+	return err
+}
+
+// check returns a *PatchConflictError if rv does not match want.
+func (p *patcher) check(rv, want reflect.Value, path Path) (err error) {
+	var d *Diff
+
+	if !want.IsValid() {
+		if !rv.IsZero() {
+			err = conflict(path, want, rv)
+		}
+		goto end
+	}
+	if want.Type() != rv.Type() {
+		err = &PatchConflictError{
+			Path:   path,
+			Detail: fmt.Sprintf("want type %s, found %s", want.Type(), rv.Type()),
+		}
+		goto end
+	}
+	d = p.diffValues(&want, &rv, path)
+	if d != nil {
+		err = conflict(path, want, rv)
+	}
+end:
+	return err
+}
+
+func conflict(path Path, want, found reflect.Value) error {
+	return &PatchConflictError{
+		Path: path,
+		Detail: fmt.Sprintf("want %s, found %s",
+			redactedString(want),
+			redactedString(found),
+		),
+	}
+}
+
+func (p *patcher) applyPointer(rv reflect.Value, d *Diff) (err error) {
+	var ptr reflect.Value
+
+	if rv.IsNil() {
+		err = conflict(d.Path, d.Want, rv)
+		goto end
+	}
+	ptr = reflect.New(rv.Type().Elem())
+	ptr.Elem().Set(rv.Elem())
+	err = p.apply(ptr.Elem(), d.Children[0])
+	if err != nil {
+		goto end
+	}
+	rv.Set(ptr)
+end:
+	return err
+}
+
+func (p *patcher) applyInterface(rv reflect.Value, d *Diff) (err error) {
+	var elem reflect.Value
+
+	child := d.Children[0]
+	if child.Kind == TypeMismatchDiff {
+		err = p.replace(rv, d)
+		goto end
+	}
+	if rv.IsNil() {
+		err = conflict(d.Path, d.Want, rv)
+		goto end
+	}
+	elem = reflect.New(rv.Elem().Type()).Elem()
+	elem.Set(rv.Elem())
+	err = p.apply(elem, child)
+	if err != nil {
+		goto end
+	}
+	rv.Set(elem)
+end:
+	return err
+}
+
+func (p *patcher) applyStruct(rv reflect.Value, d *Diff) (err error) {
+	if rv.Type() != d.Want.Type() {
+		err = p.check(rv, d.Want, d.Path)
+		goto end
+	}
+	for _, child := range d.Children {
+		err = p.apply(settable(rv.Field(child.Path.Last().Index)), child)
+		if err != nil {
+			goto end
+		}
+	}
+end:
+	return err
+}
+
+func (p *patcher) applyMap(rv reflect.Value, d *Diff) (err error) {
+	var m reflect.Value
+
+	if rv.Type() != d.Want.Type() {
+		err = p.check(rv, d.Want, d.Path)
+		goto end
+	}
+	m = reflect.MakeMapWithSize(rv.Type(), rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		m.SetMapIndex(iter.Key(), iter.Value())
+	}
+	for _, child := range d.Children {
+		key := copyOf(child.Path.Last().Key)
+		val := m.MapIndex(key)
+		switch child.Kind {
+		case MissingActualDiff:
+			if val.IsValid() {
+				err = &PatchConflictError{
+					Path:   child.Path,
+					Detail: fmt.Sprintf("want no entry, found %s", redactedString(val)),
+				}
+				goto end
+			}
+			m.SetMapIndex(key, copyOf(child.Got))
+			continue
+		case MissingExpectedDiff:
+			if !val.IsValid() {
+				err = &PatchConflictError{Path: child.Path, Detail: "found no entry"}
+				goto end
+			}
+			err = p.check(val, child.Want, child.Path)
+			if err != nil {
+				goto end
+			}
+			m.SetMapIndex(key, reflect.Value{})
+			continue
+		}
+		if !val.IsValid() {
+			err = &PatchConflictError{Path: child.Path, Detail: "found no entry"}
+			goto end
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		elem.Set(val)
+		err = p.apply(elem, child)
+		if err != nil {
+			goto end
+		}
+		m.SetMapIndex(key, elem)
+	}
+	rv.Set(m)
+end:
+	return err
+}
+
+// applyElements applies the differences between the elements of two slices or
+// arrays: changed elements at their index in want, then the elements removed,
+// from the last to the first, then those inserted at their index in got.
+func (p *patcher) applyElements(rv reflect.Value, d *Diff) (err error) {
+	var s reflect.Value
+	var removed, inserted []*Diff
+	var indexes map[*Diff]int
+
+	if rv.Type() != d.Want.Type() || rv.Len() != d.Want.Len() {
+		err = &PatchConflictError{
+			Path: d.Path,
+			Detail: fmt.Sprintf("want %s of length %d, found %s of length %d",
+				d.Want.Type(), d.Want.Len(),
+				rv.Type(), rv.Len(),
+			),
+		}
+		goto end
+	}
+	s = reflect.MakeSlice(reflect.SliceOf(rv.Type().Elem()), rv.Len(), rv.Len())
+	reflect.Copy(s, rv)
+	indexes = p.elementIndexes(s, d.Children)
+	for _, child := range d.Children {
+		switch child.Kind {
+		case MissingExpectedDiff:
+			removed = append(removed, child)
+		case MissingActualDiff:
+			inserted = append(inserted, child)
+		default:
+			err = p.apply(s.Index(indexes[child]), child)
+			if err != nil {
+				goto end
+			}
+		}
+	}
+	sort.SliceStable(removed, func(i, j int) bool {
+		return indexes[removed[i]] > indexes[removed[j]]
+	})
+	for _, child := range removed {
+		i := indexes[child]
+		err = p.check(s.Index(i), child.Want, child.Path)
+		if err != nil {
+			goto end
+		}
+		s = reflect.AppendSlice(s.Slice(0, i), s.Slice(i+1, s.Len()))
+	}
+	sort.SliceStable(inserted, func(i, j int) bool {
+		return inserted[i].Path.Last().Index < inserted[j].Path.Last().Index
+	})
+	for _, child := range inserted {
+		j := child.Path.Last().Index
+		if j > s.Len() {
+			err = &PatchConflictError{
+				Path:   child.Path,
+				Detail: fmt.Sprintf("cannot insert at index %d of length %d", j, s.Len()),
+			}
+			goto end
+		}
+		s = reflect.Append(s, reflect.Zero(s.Type().Elem()))
+		reflect.Copy(s.Slice(j+1, s.Len()), s.Slice(j, s.Len()-1))
+		s.Index(j).Set(copyOf(child.Got))
+	}
+	err = p.setElements(rv, s, d)
+end:
+	return err
+}
+
+// elementIndexes returns the index in s of the element each changed or removed
+// element in children applies to: its index in want if the element there
+// matches, or else that of another element that does. Elements compared by
+// key or ignoring order can be found elsewhere in a target patched before,
+// e.g. by Unpatch().
+func (p *patcher) elementIndexes(s reflect.Value, children []*Diff) map[*Diff]int {
+	indexes := make(map[*Diff]int, len(children))
+	used := make([]bool, s.Len())
+	matches := func(i int, child *Diff) bool {
+		if i >= s.Len() || used[i] {
+			return false
+		}
+		idx := s.Index(i)
+		return p.check(idx, child.Want, child.Path) == nil
+	}
+	var unresolved []*Diff
+	for _, child := range children {
+		if child.Kind == MissingActualDiff {
+			continue
+		}
+		i := child.Path.Last().Index
+		indexes[child] = i
+		if matches(i, child) {
+			used[i] = true
+			continue
+		}
+		unresolved = append(unresolved, child)
+	}
+	for _, child := range unresolved {
+		for i := 0; i < s.Len(); i++ {
+			if matches(i, child) {
+				indexes[child] = i
+				used[i] = true
+				break
+			}
+		}
+	}
+	return indexes
+}
+
+// setElements sets rv, a slice or array, to the elements of slice s.
+func (p *patcher) setElements(rv, s reflect.Value, d *Diff) (err error) {
+	if rv.Kind() == reflect.Slice {
+		rv.Set(s)
+		goto end
+	}
+	if s.Len() != rv.Len() {
+		err = &PatchConflictError{
+			Path:   d.Path,
+			Detail: fmt.Sprintf("cannot fit %d elements in %s", s.Len(), rv.Type()),
+		}
+		goto end
+	}
+	reflect.Copy(rv, s)
+end:
+	return err
+}
+
+// settable returns rv, or if it is an unexported struct field, an equivalent
+// value that can be set. rv must be addressable.
+func settable(rv reflect.Value) reflect.Value {
+	if rv.CanSet() {
+		return rv
+	}
+	return reflect.NewAt(rv.Type(), rv.Addr().UnsafePointer()).Elem()
+}
+
+// copyOf returns a deep copy of rv, so a target patched with it does not share
+// memory with the values a Diff was computed from.
+func copyOf(rv reflect.Value) reflect.Value {
+	dst := reflect.New(rv.Type()).Elem()
+	copyValue(dst, rv, make(map[uintptr]reflect.Value))
+	return dst
+}
+
+// copyValue deep copies src into dst, which must be settable. seen maps the
+// pointers already copied to their copies, so cycles are preserved.
+func copyValue(dst, src reflect.Value, seen map[uintptr]reflect.Value) {
+	switch src.Kind() {
+	case reflect.Pointer:
+		if src.IsNil() {
+			break
+		}
+		ptr, ok := seen[src.Pointer()]
+		if !ok {
+			ptr = reflect.New(src.Type().Elem())
+			seen[src.Pointer()] = ptr
+			copyValue(ptr.Elem(), src.Elem(), seen)
+		}
+		dst.Set(ptr)
+	case reflect.Interface:
+		if src.IsNil() {
+			break
+		}
+		elem := reflect.New(src.Elem().Type()).Elem()
+		copyValue(elem, src.Elem(), seen)
+		dst.Set(elem)
+	case reflect.Struct:
+		for i := 0; i < src.NumField(); i++ {
+			copyValue(settable(dst.Field(i)), src.Field(i), seen)
+		}
+	case reflect.Array:
+		for i := 0; i < src.Len(); i++ {
+			copyValue(dst.Index(i), src.Index(i), seen)
+		}
+	case reflect.Slice:
+		if src.IsNil() {
+			break
+		}
+		s := reflect.MakeSlice(src.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			copyValue(s.Index(i), src.Index(i), seen)
+		}
+		dst.Set(s)
+	case reflect.Map:
+		if src.IsNil() {
+			break
+		}
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		for iter := src.MapRange(); iter.Next(); {
+			key := reflect.New(src.Type().Key()).Elem()
+			copyValue(key, iter.Key(), seen)
+			val := reflect.New(src.Type().Elem()).Elem()
+			copyValue(val, iter.Value(), seen)
+			m.SetMapIndex(key, val)
+		}
+		dst.Set(m)
+	case reflect.Bool:
+		dst.SetBool(src.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		dst.SetInt(src.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		dst.SetUint(src.Uint())
+	case reflect.Float32, reflect.Float64:
+		dst.SetFloat(src.Float())
+	case reflect.Complex64, reflect.Complex128:
+		dst.SetComplex(src.Complex())
+	case reflect.String:
+		dst.SetString(src.String())
+	default:
+		// Channels, funcs and unsafe pointers refer to the same thing when copied
+		if v, ok := interfaceOf(&src); ok && v != nil {
+			dst.Set(reflect.ValueOf(v))
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package diffator_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type PatchState struct {
+	Name    string
+	Tags    []string
+	Meta    map[string]int
+	Owner   *PatchOwner
+	Any     any
+	Users   []KeyedUser
+	Scores  [3]int
+	private string
+}
+
+func newPatchState() PatchState {
+	return PatchState{
+		Name:    "web",
+		Tags:    []string{"a", "b", "c", "d", "e"},
+		Meta:    map[string]int{"x": 1, "y": 2},
+		Owner:   &PatchOwner{Email: "a@x"},
+		Any:     1,
+		Users:   []KeyedUser{{ID: 1, Email: "a@x"}, {ID: 2, Email: "b@x"}, {ID: 3, Email: "c@x"}},
+		Scores:  [3]int{1, 2, 3},
+		private: "p",
+	}
+}
+
+func TestPatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(s *PatchState)
+		opts   *diffator.ObjectOpts
+	}{
+		{
+			name: "Fields",
+			change: func(s *PatchState) {
+				s.Name = "api"
+				s.private = "q"
+				s.Owner = &PatchOwner{Email: "b@x"}
+			},
+		},
+		{
+			name: "Slice elements",
+			change: func(s *PatchState) {
+				s.Tags = []string{"x", "a", "B", "c", "e", "f"}
+			},
+		},
+		{
+			name: "Slice elements by index",
+			change: func(s *PatchState) {
+				s.Tags = []string{"A", "b"}
+			},
+			opts: &diffator.ObjectOpts{SliceIndexMode: true},
+		},
+		{
+			name: "Map entries",
+			change: func(s *PatchState) {
+				s.Meta = map[string]int{"y": 3, "z": 4}
+			},
+		},
+		{
+			name: "Nil and non-nil",
+			change: func(s *PatchState) {
+				s.Owner = nil
+				s.Meta = nil
+				s.Any = nil
+			},
+		},
+		{
+			name: "Interface of another type",
+			change: func(s *PatchState) {
+				s.Any = "one"
+			},
+		},
+		{
+			name: "Array",
+			change: func(s *PatchState) {
+				s.Scores = [3]int{0, 1, 2}
+			},
+		},
+		{
+			name: "Unordered slice",
+			change: func(s *PatchState) {
+				s.Tags = []string{"e", "x", "c", "B", "a"}
+			},
+			opts: &diffator.ObjectOpts{UnorderedSlices: true},
+		},
+		{
+			name: "Keyed slice",
+			change: func(s *PatchState) {
+				s.Users = []KeyedUser{{ID: 3, Email: "C@x"}, {ID: 1, Email: "a@x"}, {ID: 4, Email: "d@x"}}
+			},
+			opts: &diffator.ObjectOpts{
+				SliceKeys: []diffator.SliceKey{diffator.KeyField[KeyedUser]("ID")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := newPatchState()
+			got := newPatchState()
+			tt.change(&got)
+			d := diffator.CompareObjectsResult(want, got, tt.opts)
+			require.NotNil(t, d)
+
+			target := newPatchState()
+			require.NoError(t, diffator.Patch(&target, d))
+			assert.Nil(t, diffator.CompareObjectsResult(got, target, tt.opts), "Patch")
+
+			require.NoError(t, diffator.Unpatch(&target, d))
+			assert.Nil(t, diffator.CompareObjectsResult(want, target, tt.opts), "Unpatch")
+		})
+	}
+}
+
+func TestPatchDoesNotShareMemory(t *testing.T) {
+	want := newPatchState()
+	got := newPatchState()
+	got.Tags[0] = "z"
+	got.Owner.Email = "b@x"
+	d := diffator.CompareObjectsResult(&want, &got, nil)
+
+	target := want
+	require.NoError(t, diffator.Patch(&target, d))
+	assert.Equal(t, "z", target.Tags[0])
+	assert.Equal(t, "b@x", target.Owner.Email)
+	assert.Equal(t, "a", want.Tags[0])
+	assert.Equal(t, "a@x", want.Owner.Email)
+
+	require.NoError(t, diffator.Unpatch(&target, d))
+	assert.Equal(t, want, target)
+}
+
+func TestPatchConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(s *PatchState)
+		target  func(s *PatchState)
+		wantErr string
+	}{
+		{
+			name:    "Field",
+			change:  func(s *PatchState) { s.Name = "api" },
+			target:  func(s *PatchState) { s.Name = "other" },
+			wantErr: `patch conflict at Name: want "web", found "other"`,
+		},
+		{
+			name:    "Slice length",
+			change:  func(s *PatchState) { s.Tags = s.Tags[:4] },
+			target:  func(s *PatchState) { s.Tags = s.Tags[:3] },
+			wantErr: `patch conflict at Tags: want []string of length 5, found []string of length 3`,
+		},
+		{
+			name:    "Map entry",
+			change:  func(s *PatchState) { s.Meta["z"] = 3 },
+			target:  func(s *PatchState) { s.Meta["z"] = 4 },
+			wantErr: `patch conflict at Meta["z"]: want no entry, found 4`,
+		},
+		{
+			name:    "Removed map entry",
+			change:  func(s *PatchState) { delete(s.Meta, "x") },
+			target:  func(s *PatchState) { delete(s.Meta, "x") },
+			wantErr: `patch conflict at Meta["x"]: found no entry`,
+		},
+		{
+			name:    "Nil pointer",
+			change:  func(s *PatchState) { s.Owner = &PatchOwner{Email: "b@x"} },
+			target:  func(s *PatchState) { s.Owner = nil },
+			wantErr: `patch conflict at Owner: want *diffator_test.PatchOwner{Email:"a@x",}, found *nil`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newPatchState()
+			tt.change(&got)
+			d := diffator.CompareObjectsResult(newPatchState(), got, nil)
+
+			target := newPatchState()
+			tt.target(&target)
+			before := newPatchState()
+			tt.target(&before)
+			err := diffator.Patch(&target, d)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, diffator.ErrPatchConflict))
+			assert.Equal(t, tt.wantErr, err.Error())
+			assert.Equal(t, before, target, "target must be unchanged")
+		})
+	}
+}
+
+type PatchReading struct {
+	Value float64
+	At    time.Time
+	Owner *PatchOwner
+}
+
+func TestPatchWithOpts(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	want := PatchReading{Value: 1, At: at, Owner: &PatchOwner{Email: "a@x"}}
+	got := PatchReading{Value: 2, At: at.Add(2 * time.Hour)}
+	opts := &diffator.ObjectOpts{
+		FloatAbsEpsilon: 0.01,
+		Comparers: []diffator.CustomComparer{
+			diffator.Comparer(func(a, b time.Time) bool {
+				return a.Truncate(time.Hour).Equal(b.Truncate(time.Hour))
+			}),
+		},
+		Ignore: []diffator.IgnoreRule{diffator.IgnorePath("Owner.Email")},
+	}
+	d := diffator.CompareObjectsResult(want, got, opts)
+	require.NotNil(t, d)
+
+	// The target matches want only as compared with opts
+	target := PatchReading{Value: 1.001, At: at.Add(10 * time.Minute), Owner: &PatchOwner{Email: "b@x"}}
+	require.NoError(t, diffator.Patch(&target, d))
+	assert.Equal(t, got, target)
+
+	target = PatchReading{Value: 2.001, At: at.Add(130 * time.Minute)}
+	require.NoError(t, diffator.Unpatch(&target, d))
+	assert.Equal(t, want, target)
+
+	target = PatchReading{Value: 1.1, At: at, Owner: &PatchOwner{Email: "a@x"}}
+	assert.EqualError(t, diffator.Patch(&target, d), "patch conflict at Value: want 1, found 1.1")
+}
+
+func TestPatchTarget(t *testing.T) {
+	d := diffator.CompareObjectsResult(1, 2, nil)
+	assert.Error(t, diffator.Patch(1, d))
+	assert.Error(t, diffator.Patch((*int)(nil), d))
+	assert.NoError(t, diffator.Patch(new(int), nil))
+	n := 1
+	require.NoError(t, diffator.Patch(&n, d))
+	assert.Equal(t, 2, n)
+}
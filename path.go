@@ -0,0 +1,100 @@
+package diffator
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PathStepKind identifies how a PathStep descends from its parent value.
+type PathStepKind int
+
+const (
+	// FieldStep descends into a struct field.
+	FieldStep PathStepKind = iota
+	// IndexStep descends into a slice or array element.
+	IndexStep
+	// MapKeyStep descends into a map entry.
+	MapKeyStep
+	// ElementKeyStep descends into a slice or array element matched by a
+	// SliceKey rather than by its index.
+	ElementKeyStep
+)
+
+// PathStep is one step of a Path.
+type PathStep struct {
+	Kind  PathStepKind
+	Name  string        // Field name for FieldStep, or key name for ElementKeyStep
+	Index int           // Element index for IndexStep and ElementKeyStep, or field index for FieldStep
+	Key   reflect.Value // Map key for MapKeyStep, or element key for ElementKeyStep
+}
+
+func (s PathStep) String() (str string) {
+	switch s.Kind {
+	case FieldStep:
+		str = "." + s.Name
+	case IndexStep:
+		str = fmt.Sprintf("[%d]", s.Index)
+	case MapKeyStep:
+		str = fmt.Sprintf("[%s]", keyString(s.Key))
+	case ElementKeyStep:
+		str = fmt.Sprintf("[%s=%s]", s.Name, keyString(s.Key))
+	}
+	return str
+}
+
+// Path is the location of a value relative to the root of a comparison, e.g.
+// `Users[2].Email`, `Users[id=42].Email` or `Meta["owner"]`. The root itself
+// has an empty path.
+type Path []PathStep
+
+func (p Path) String() string {
+	sb := strings.Builder{}
+	for _, step := range p {
+		sb.WriteString(step.String())
+	}
+	return strings.TrimPrefix(sb.String(), ".")
+}
+
+// Last returns the final step of the path, or a zero PathStep for the root.
+func (p Path) Last() (step PathStep) {
+	if len(p) > 0 {
+		step = p[len(p)-1]
+	}
+	return step
+}
+
+// with returns a copy of the path with step appended. Paths are shared between
+// parents and children in a Diff tree so they must never be appended in place.
+func (p Path) with(step PathStep) Path {
+	path := make(Path, len(p), len(p)+1)
+	copy(path, p)
+	return append(path, step)
+}
+
+func (p Path) withField(name string, index int) Path {
+	return p.with(PathStep{Kind: FieldStep, Name: name, Index: index})
+}
+
+func (p Path) withIndex(index int) Path {
+	return p.with(PathStep{Kind: IndexStep, Index: index})
+}
+
+func (p Path) withKey(key reflect.Value) Path {
+	return p.with(PathStep{Kind: MapKeyStep, Key: key})
+}
+
+func (p Path) withElementKey(name string, key reflect.Value, index int) Path {
+	return p.with(PathStep{Kind: ElementKeyStep, Name: name, Key: key, Index: index})
+}
+
+func keyString(key reflect.Value) (s string) {
+	if key.Kind() == reflect.String {
+		s = strconv.Quote(key.String())
+		goto end
+	}
+	s = fmt.Sprintf("%v", key)
+end:
+	return s
+}
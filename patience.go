@@ -0,0 +1,172 @@
+package diffator
+
+// lineRun is a run of length equal lines found at pos1 in one sequence of
+// lines and at pos2 in another.
+type lineRun struct {
+	pos1   int
+	pos2   int
+	length int
+}
+
+// matchLines finds the lines common to a and b using patience diff: after
+// matching any common prefix and suffix, the lines occurring exactly once in
+// both are matched in order by finding their longest increasing subsequence,
+// and then the gaps between those lines are matched the same way. Unlike
+// longestCommonSubstr it does not need to revisit the whole input for every
+// difference found, so it scales to inputs with many differences.
+func matchLines(a, b []int32) (runs []lineRun) {
+	var ids int32
+	for _, id := range a {
+		ids = max(ids, id+1)
+	}
+	for _, id := range b {
+		ids = max(ids, id+1)
+	}
+	m := lineMatcher{
+		a:     a,
+		b:     b,
+		seen1: make([]int32, ids),
+		seen2: make([]int32, ids),
+	}
+	m.matchRange(0, len(a), 0, len(b))
+	return m.runs
+}
+
+// lineMatcher holds the state of matchLines. Lines are ids from an interner,
+// so the lines occurring once in a range are counted in slices indexed by id,
+// which are cleared after each use, rather than in maps built for every range.
+type lineMatcher struct {
+	a     []int32
+	b     []int32
+	seen1 []int32 // 1 + the position of each line in a, or duplicate
+	seen2 []int32 // 1 + the position of each line in b, or duplicate
+	runs  []lineRun
+}
+
+func (m *lineMatcher) matchRange(lo1, hi1, lo2, hi2 int) {
+	a, b := m.a, m.b
+	start1, start2 := lo1, lo2
+	for lo1 < hi1 && lo2 < hi2 && a[lo1] == b[lo2] {
+		lo1++
+		lo2++
+	}
+	if lo1 > start1 {
+		m.addRun(lineRun{pos1: start1, pos2: start2, length: lo1 - start1})
+	}
+
+	end1 := hi1
+	for hi1 > lo1 && hi2 > lo2 && a[hi1-1] == b[hi2-1] {
+		hi1--
+		hi2--
+	}
+
+	anchors := m.uniqueAnchors(lo1, hi1, lo2, hi2)
+	for _, anchor := range anchors {
+		m.matchRange(lo1, anchor.pos1, lo2, anchor.pos2)
+		m.addRun(anchor)
+		lo1, lo2 = anchor.pos1+1, anchor.pos2+1
+	}
+	if len(anchors) > 0 {
+		m.matchRange(lo1, hi1, lo2, hi2)
+	}
+
+	if end1 > hi1 {
+		m.addRun(lineRun{pos1: hi1, pos2: hi2, length: end1 - hi1})
+	}
+}
+
+// addRun appends run to the runs found, joining it to the last run if it
+// follows on directly from it.
+func (m *lineMatcher) addRun(run lineRun) {
+	n := len(m.runs)
+	if n > 0 {
+		last := &m.runs[n-1]
+		if last.pos1+last.length == run.pos1 && last.pos2+last.length == run.pos2 {
+			last.length += run.length
+			return
+		}
+	}
+	m.runs = append(m.runs, run)
+}
+
+// uniqueAnchors returns the longest increasing subsequence of the lines that
+// occur exactly once in both a[lo1:hi1] and b[lo2:hi2].
+func (m *lineMatcher) uniqueAnchors(lo1, hi1, lo2, hi2 int) (anchors []lineRun) {
+	var pairs []lineRun
+
+	if lo1 == hi1 || lo2 == hi2 {
+		return nil
+	}
+	countLines(m.a[lo1:hi1], lo1, m.seen1)
+	countLines(m.b[lo2:hi2], lo2, m.seen2)
+	for i := lo1; i < hi1; i++ {
+		id := m.a[i]
+		if m.seen1[id] != int32(i+1) || m.seen2[id] <= 0 {
+			continue
+		}
+		pairs = append(pairs, lineRun{pos1: i, pos2: int(m.seen2[id] - 1), length: 1})
+	}
+	clearLines(m.a[lo1:hi1], m.seen1)
+	clearLines(m.b[lo2:hi2], m.seen2)
+	return longestIncreasingRuns(pairs)
+}
+
+// duplicateLine marks a line seen more than once by countLines.
+const duplicateLine = -1
+
+// countLines records in seen 1 + the position of each line of lines, which
+// starts at offset, or duplicateLine for a line seen more than once.
+func countLines(lines []int32, offset int, seen []int32) {
+	for i, id := range lines {
+		switch seen[id] {
+		case 0:
+			seen[id] = int32(offset + i + 1)
+		default:
+			seen[id] = duplicateLine
+		}
+	}
+}
+
+// clearLines resets the entries of seen set by countLines.
+func clearLines(lines []int32, seen []int32) {
+	for _, id := range lines {
+		seen[id] = 0
+	}
+}
+
+// longestIncreasingRuns returns the longest subsequence of pairs, which are
+// ordered by pos1, that is also ordered by pos2.
+func longestIncreasingRuns(pairs []lineRun) (lis []lineRun) {
+	if len(pairs) == 0 {
+		return nil
+	}
+	// tails[k] is the index in pairs of the smallest pos2 ending an increasing
+	// subsequence of length k+1, and prev links each pair to its predecessor.
+	tails := make([]int, 0, len(pairs))
+	prev := make([]int, len(pairs))
+	for i, pair := range pairs {
+		lo, hi := 0, len(tails)
+		for lo < hi {
+			mid := (lo + hi) / 2
+			if pairs[tails[mid]].pos2 < pair.pos2 {
+				lo = mid + 1
+			} else {
+				hi = mid
+			}
+		}
+		prev[i] = -1
+		if lo > 0 {
+			prev[i] = tails[lo-1]
+		}
+		if lo == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[lo] = i
+		}
+	}
+	lis = make([]lineRun, len(tails))
+	for i, k := tails[len(tails)-1], len(tails)-1; i != -1; i, k = prev[i], k-1 {
+		lis[k] = pairs[i]
+	}
+	return lis
+}
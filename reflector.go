@@ -9,6 +9,12 @@ import (
 
 type Reflector struct {
 	*reflect.Value
+	// Package is the name of the package whose types are written without a
+	// package qualifier by AsGoSyntax(), e.g. "mypkg_test".
+	Package string
+	// Redact writes Redacted in place of the values of struct fields tagged
+	// `diff:"redact"` in the output of String() and AsString().
+	Redact   bool
 	original any
 	tracker  *Tracker
 }
@@ -79,10 +85,28 @@ func (r *Reflector) AsAny(rv *reflect.Value) (a any) {
 		a = float32(rv.Float())
 	case reflect.Float64:
 		a = rv.Float()
+	case reflect.Uint:
+		a = uint(rv.Uint())
+	case reflect.Uint8:
+		a = uint8(rv.Uint())
+	case reflect.Uint16:
+		a = uint16(rv.Uint())
+	case reflect.Uint32:
+		a = uint32(rv.Uint())
+	case reflect.Uint64:
+		a = rv.Uint()
+	case reflect.Uintptr:
+		a = uintptr(rv.Uint())
+	case reflect.Complex64:
+		a = complex64(rv.Complex())
+	case reflect.Complex128:
+		a = rv.Complex()
 	case reflect.Map:
 		a = map[any]any{"<example>": "<example>"}
-	case reflect.Slice:
+	case reflect.Slice, reflect.Array:
 		a = []any{"<example>"}
+	case reflect.Chan, reflect.Func, reflect.Struct:
+		a = r.AsString(rv)
 	case reflect.UnsafePointer:
 		a = "*<example>"
 	case reflect.Interface, reflect.Pointer:
@@ -157,6 +181,12 @@ func (r *Reflector) AsString(rv *reflect.Value) (s string) {
 		s = strconv.FormatFloat(rv.Float(), 'g', 10, 32)
 	case reflect.Float64:
 		s = strconv.FormatFloat(rv.Float(), 'g', 10, 64)
+	case reflect.Complex64:
+		s = strconv.FormatComplex(rv.Complex(), 'g', 10, 64)
+	case reflect.Complex128:
+		s = strconv.FormatComplex(rv.Complex(), 'g', 10, 128)
+	case reflect.Chan:
+		s = chanString(rv)
 	case reflect.Map:
 		sb := strings.Builder{}
 		sb.WriteString(r.TypenameOf(rv))
@@ -191,7 +221,11 @@ func (r *Reflector) AsString(rv *reflect.Value) (s string) {
 			sb.WriteString(rt.Field(i).Name)
 			sb.WriteByte(':')
 			fld := rv.Field(i)
-			sb.WriteString(r.AsString(&fld))
+			if r.Redact && structTags(rt)[i].redact {
+				sb.WriteString(Redacted)
+			} else {
+				sb.WriteString(r.AsString(&fld))
+			}
 			sb.WriteByte(',')
 		}
 		sb.WriteByte('}')
@@ -210,3 +244,17 @@ func (r *Reflector) AsString(rv *reflect.Value) (s string) {
 end:
 	return s
 }
+
+// chanString describes a channel by its type, which includes its direction and
+// element type, and its length and capacity, e.g. `chan int{len:1,cap:2}`. A
+// nil channel is `chan int(nil)`. Its address is left out so that output is the
+// same from run to run.
+func chanString(rv *reflect.Value) (s string) {
+	if rv.IsNil() {
+		s = fmt.Sprintf("%s(nil)", rv.Type())
+		goto end
+	}
+	s = fmt.Sprintf("%s{len:%d,cap:%d}", rv.Type(), rv.Len(), rv.Cap())
+end:
+	return s
+}
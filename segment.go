@@ -0,0 +1,17 @@
+package diffator
+
+type segmentKind int
+
+const (
+	bothSegment segmentKind = iota
+	leftSegment
+	rightSegment
+)
+
+// segment is a run of text that is either common to both strings, or only
+// found in the left or the right string. Flattening a tree into segments lets
+// renderers other than the inline one walk the differences in order.
+type segment struct {
+	kind segmentKind
+	text string
+}
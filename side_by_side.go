@@ -0,0 +1,658 @@
+package diffator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Markers in the gutter between the columns of SideBySide output.
+const (
+	bothMarker     = " "
+	changedMarker  = "|"
+	leftMarker     = "<"
+	rightMarker    = ">"
+	gutterWidth    = len(" | ")
+	minColumnWidth = 8
+	tabWidth       = 8
+)
+
+// sideBySideRow is a row of SideBySide output: a line of want on the left, a
+// line of got on the right, or both, with the marker between them.
+type sideBySideRow struct {
+	left   string
+	right  string
+	marker string
+}
+
+// sideBySideRows splits segments into rows, pairing the lines removed by each
+// run of changed lines with the lines added in their place.
+func sideBySideRows(segs []segment) (rows []sideBySideRow) {
+	var left, right []diffLine
+	flush := func() {
+		for k := 0; k < max(len(left), len(right)); k++ {
+			row := sideBySideRow{marker: changedMarker}
+			switch {
+			case k >= len(right):
+				row.left, row.marker = left[k].text, leftMarker
+			case k >= len(left):
+				row.right, row.marker = right[k].text, rightMarker
+			default:
+				row.left, row.right = left[k].text, right[k].text
+			}
+			rows = append(rows, row)
+		}
+		left, right = nil, nil
+	}
+	for _, seg := range segs {
+		split := splitLines(seg)
+		switch seg.kind {
+		case leftSegment:
+			left = append(left, split...)
+		case rightSegment:
+			right = append(right, split...)
+		default:
+			flush()
+			for _, line := range split {
+				rows = append(rows, sideBySideRow{
+					left:   line.text,
+					right:  line.text,
+					marker: bothMarker,
+				})
+			}
+		}
+	}
+	flush()
+	return rows
+}
+
+// renderSideBySide renders segments in two columns that fit in Width, want on
+// the left and got on the right, e.g.:
+//
+//	host = localhost          host = localhost
+//	port = 80               | port = 8080
+//	                        > tls = on
+//
+// Lines too long for their column are wrapped onto the rows that follow, and
+// runs of unchanged lines beyond ContextLines are elided.
+func (opts *StringOpts) renderSideBySide(segs []segment) string {
+	return opts.renderRows(sideBySideRows(segs))
+}
+
+// renderRows renders rows as renderSideBySide() does, or returns "" if none of
+// them differ.
+func (opts *StringOpts) renderRows(rows []sideBySideRow) (s string) {
+	var out []string
+	var elided bool
+
+	show, changed := opts.contextMaskOf(len(rows), func(i int) bool {
+		return rows[i].marker != bothMarker
+	})
+	if !changed {
+		goto end
+	}
+	for i, row := range rows {
+		if !show[i] {
+			if !elided {
+				out = append(out, opts.style.Context(elidedLines))
+			}
+			elided = true
+			continue
+		}
+		elided = false
+		out = append(out, row.render(opts.columnWidth(), opts.style)...)
+	}
+	s = strings.Join(out, "\n")
+end:
+	return s
+}
+
+// columnWidth returns the width of each column of SideBySide output.
+func (opts *StringOpts) columnWidth() int {
+	return max((opts.Width.Value-gutterWidth)/2, minColumnWidth)
+}
+
+// render returns the output lines of row, more than one if the left or right
+// line is wrapped, with the left column padded to width.
+func (row sideBySideRow) render(width int, st Styler) (lines []string) {
+	styleLeft, styleRight := st.Context, st.Context
+	if row.marker != bothMarker {
+		styleLeft, styleRight = st.Want, st.Got
+	}
+	left := wrapLine(expandTabs(row.left), width)
+	right := wrapLine(expandTabs(row.right), width)
+	for k := 0; k < max(len(left), len(right)); k++ {
+		var l, r string
+		if k < len(left) {
+			l = left[k]
+		}
+		if k < len(right) {
+			r = right[k]
+		}
+		sb := strings.Builder{}
+		if l != "" {
+			sb.WriteString(styleLeft(l))
+		}
+		sb.WriteString(strings.Repeat(" ", max(width-displayWidth(l), 0)+1))
+		sb.WriteString(row.marker)
+		if r != "" {
+			sb.WriteByte(' ')
+			sb.WriteString(styleRight(r))
+		}
+		lines = append(lines, strings.TrimRight(sb.String(), " "))
+	}
+	return lines
+}
+
+// wrapLine splits s into pieces at most width columns wide, per runeWidth().
+func wrapLine(s string, width int) (pieces []string) {
+	start, w := 0, 0
+	for i, r := range s {
+		rw := runeWidth(r)
+		if w+rw > width && i > start {
+			pieces = append(pieces, s[start:i])
+			start, w = i, 0
+		}
+		w += rw
+	}
+	return append(pieces, s[start:])
+}
+
+// displayWidth returns the number of columns s takes in a terminal.
+func displayWidth(s string) (width int) {
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// runeWidth returns the number of columns r takes in a terminal: 0 for marks
+// that combine with the rune before them and for format characters such as
+// zero-width joiners, 2 for East Asian wide and fullwidth runes and for emoji,
+// and 1 otherwise.
+func runeWidth(r rune) (width int) {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		width = 0
+	case unicode.Is(wideRunes, r):
+		width = 2
+	default:
+		width = 1
+	}
+	return width
+}
+
+// wideRunes holds the runes of East Asian Width W or F in Unicode 15, with a
+// few rarely used ranges approximated, which include most emoji.
+var wideRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x1100, Hi: 0x115f, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2329, Hi: 0x232a, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23ec, Stride: 1},
+		{Lo: 0x23f0, Hi: 0x23f0, Stride: 1},
+		{Lo: 0x23f3, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x25fd, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2615, Stride: 1},
+		{Lo: 0x2648, Hi: 0x2653, Stride: 1},
+		{Lo: 0x267f, Hi: 0x267f, Stride: 1},
+		{Lo: 0x2693, Hi: 0x2693, Stride: 1},
+		{Lo: 0x26a1, Hi: 0x26a1, Stride: 1},
+		{Lo: 0x26aa, Hi: 0x26ab, Stride: 1},
+		{Lo: 0x26bd, Hi: 0x26be, Stride: 1},
+		{Lo: 0x26c4, Hi: 0x26c5, Stride: 1},
+		{Lo: 0x26ce, Hi: 0x26ce, Stride: 1},
+		{Lo: 0x26d4, Hi: 0x26d4, Stride: 1},
+		{Lo: 0x26ea, Hi: 0x26ea, Stride: 1},
+		{Lo: 0x26f2, Hi: 0x26f3, Stride: 1},
+		{Lo: 0x26f5, Hi: 0x26f5, Stride: 1},
+		{Lo: 0x26fa, Hi: 0x26fa, Stride: 1},
+		{Lo: 0x26fd, Hi: 0x26fd, Stride: 1},
+		{Lo: 0x2705, Hi: 0x2705, Stride: 1},
+		{Lo: 0x270a, Hi: 0x270b, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x274e, Hi: 0x274e, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27b0, Stride: 1},
+		{Lo: 0x27bf, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: 0x2e80, Hi: 0x303e, Stride: 1},
+		{Lo: 0x3041, Hi: 0x4dbf, Stride: 1},
+		{Lo: 0x4e00, Hi: 0xa4cf, Stride: 1},
+		{Lo: 0xa960, Hi: 0xa97f, Stride: 1},
+		{Lo: 0xac00, Hi: 0xd7a3, Stride: 1},
+		{Lo: 0xf900, Hi: 0xfaff, Stride: 1},
+		{Lo: 0xfe10, Hi: 0xfe19, Stride: 1},
+		{Lo: 0xfe30, Hi: 0xfe6f, Stride: 1},
+		{Lo: 0xff00, Hi: 0xff60, Stride: 1},
+		{Lo: 0xffe0, Hi: 0xffe6, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x16fe0, Hi: 0x16fe4, Stride: 1},
+		{Lo: 0x17000, Hi: 0x18cff, Stride: 1},
+		{Lo: 0x1b000, Hi: 0x1b2ff, Stride: 1},
+		{Lo: 0x1f004, Hi: 0x1f004, Stride: 1},
+		{Lo: 0x1f0cf, Hi: 0x1f0cf, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f200, Hi: 0x1f2ff, Stride: 1},
+		{Lo: 0x1f300, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f7e0, Hi: 0x1f7eb, Stride: 1},
+		{Lo: 0x1f90c, Hi: 0x1f9ff, Stride: 1},
+		{Lo: 0x1fa70, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x20000, Hi: 0x3fffd, Stride: 1},
+	},
+}
+
+// expandTabs replaces the tabs in s with spaces up to the next tab stop, so
+// that the columns of SideBySide output line up.
+func expandTabs(s string) string {
+	if !strings.Contains(s, "\t") {
+		return s
+	}
+	sb := strings.Builder{}
+	col := 0
+	for _, r := range s {
+		if r != '\t' {
+			sb.WriteRune(r)
+			col += runeWidth(r)
+			continue
+		}
+		n := tabWidth - col%tabWidth
+		sb.WriteString(strings.Repeat(" ", n))
+		col += n
+	}
+	return sb.String()
+}
+
+// renderSideBySide renders the want and got values of d in two columns with
+// StringOpts.SideBySide, each value pretty printed with one field, element or
+// map entry per line. The lines are marked as d has them differ, so values the
+// comparison found equal, e.g. by an Equal method or within a tolerance, are
+// not marked even if they print differently. All lines are shown unless
+// StringOpts sets ContextLines.
+func (o *ObjectComparator) renderSideBySide(d *Diff) (diff string) {
+	opts := o.stringOpts()
+	opts.SideBySide = Bool(true)
+	opts.Width = o.opts.Width
+	if opts.ContextLines == nil {
+		opts.ContextLines = Int(-1)
+	}
+	opts.SetDefaults()
+	sw := sideBySideWriter{ObjectComparator: o}
+	level := o.level
+	o.level = 0
+	sw.writeEntry(d, d.Want, d.Got, d.Path, "", "")
+	o.level = level
+	diff = opts.renderRows(sw.rows)
+	if diff == "" {
+		// The values differ in ways their pretty printed forms do not show
+		diff = o.renderDiff(d, "%s")
+	}
+	return diff
+}
+
+// sideBySideWriter writes the rows of renderSideBySide() for a Diff, walking it
+// alongside the want and got values it was computed from.
+type sideBySideWriter struct {
+	*ObjectComparator
+	rows []sideBySideRow
+}
+
+// writeEntry writes the rows for rv1 and rv2, found at path, which differ as d
+// has it, or are equal if d is nil. The first line of each is prefixed with
+// prefix and the last suffixed with suffix.
+func (sw *sideBySideWriter) writeEntry(d *Diff, rv1, rv2 reflect.Value, path Path, prefix, suffix string) {
+	switch {
+	case d == nil:
+		sw.writeLines(sw.pretty(rv1, path, prefix, suffix), sw.pretty(rv2, path, prefix, suffix), bothMarker)
+	case d.Kind == MissingExpectedDiff:
+		sw.writeLines(sw.pretty(rv1, path, prefix, suffix), nil, leftMarker)
+	case d.Kind == MissingActualDiff:
+		sw.writeLines(nil, sw.pretty(rv2, path, prefix, suffix), rightMarker)
+	case d.IsLeaf() || d.Redacted || d.Kind != ChangedDiff || isBytesType(rv1.Type()):
+		sw.writeLines(sw.pretty(rv1, path, prefix, suffix), sw.pretty(rv2, path, prefix, suffix), changedMarker)
+	case rv1.Kind() == reflect.Pointer:
+		sw.writeEntry(d.Children[0], rv1.Elem(), rv2.Elem(), path, prefix+"*", suffix)
+	case rv1.Kind() == reflect.Interface:
+		sw.writeEntry(d.Children[0], rv1.Elem(), rv2.Elem(), path, prefix, suffix)
+	case rv1.Kind() == reflect.Struct:
+		sw.writeStruct(d, rv1, rv2, path, prefix, suffix)
+	case rv1.Kind() == reflect.Map:
+		sw.writeMap(d, rv1, rv2, path, prefix, suffix)
+	case rv1.Kind() == reflect.Slice, rv1.Kind() == reflect.Array:
+		sw.writeElements(d, rv1, rv2, path, prefix, suffix)
+	default:
+		sw.writeLines(sw.pretty(rv1, path, prefix, suffix), sw.pretty(rv2, path, prefix, suffix), changedMarker)
+	}
+}
+
+// writeStruct writes the fields of structs rv1 and rv2, which differ as d has
+// it, leaving out those that are not compared.
+func (sw *sideBySideWriter) writeStruct(d *Diff, rv1, rv2 reflect.Value, path Path, prefix, suffix string) {
+	children := make(map[int]*Diff, len(d.Children))
+	for _, child := range d.Children {
+		children[child.Path.Last().Index] = child
+	}
+	sw.open(rv1, prefix)
+	for i, tag := range structTags(rv1.Type()) {
+		fld1, fld2 := rv1.Field(i), rv2.Field(i)
+		fldPath := path.withField(tag.name, i)
+		child := children[i]
+		switch {
+		case tag.skip, sw.ignored(fldPath, &fld1, &fld2):
+		case child == nil && !isCompared(fld1.Kind()):
+		case tag.redact && child == nil:
+			sw.writeLines([]string{sw.indent() + tag.name + ":" + Redacted + ","}, []string{sw.indent() + tag.name + ":" + Redacted + ","}, bothMarker)
+		case tag.redact:
+			sw.writeLines([]string{sw.indent() + tag.name + ":" + Redacted + ","}, []string{sw.indent() + tag.name + ":" + Redacted + " (changed),"}, changedMarker)
+		default:
+			sw.writeEntry(child, fld1, fld2, fldPath, tag.name+":", ",")
+		}
+	}
+	sw.close(suffix)
+}
+
+// writeMap writes the entries of maps rv1 and rv2, which differ as d has it,
+// those of rv1 in order of their keys followed by those only rv2 has.
+func (sw *sideBySideWriter) writeMap(d *Diff, rv1, rv2 reflect.Value, path Path, prefix, suffix string) {
+	children := make(map[string]*Diff, len(d.Children))
+	for _, child := range d.Children {
+		children[keyString(child.Path.Last().Key)] = child
+	}
+	keys := SortedMapKeys(&rv1)
+	for _, key := range SortedMapKeys(&rv2) {
+		if !rv1.MapIndex(key).IsValid() {
+			keys = append(keys, key)
+		}
+	}
+	sw.open(rv1, prefix)
+	for _, key := range keys {
+		val1, val2 := rv1.MapIndex(key), rv2.MapIndex(key)
+		child := children[keyString(key)]
+		if child == nil && (!val1.IsValid() || !val2.IsValid()) {
+			// The entry is ignored
+			continue
+		}
+		sw.writeEntry(child, val1, val2, path.withKey(key), NewReflector(key).String()+":", ",")
+	}
+	sw.close(suffix)
+}
+
+// writeElements writes the elements of slices or arrays rv1 and rv2, which
+// differ as d has it, in the order of rv1 with the elements inserted in rv2
+// placed before the element of rv2 that follows them.
+func (sw *sideBySideWriter) writeElements(d *Diff, rv1, rv2 reflect.Value, path Path, prefix, suffix string) {
+	var inserted []*Diff
+
+	changed := make(map[int]*Diff, len(d.Children))
+	deleted := make(map[int]*Diff, len(d.Children))
+	claimed := make(map[int]bool, len(d.Children))
+	for _, child := range d.Children {
+		index := child.Path.Last().Index
+		switch child.Kind {
+		case MissingExpectedDiff:
+			deleted[index] = child
+		case MissingActualDiff:
+			inserted = append(inserted, child)
+			claimed[index] = true
+		default:
+			changed[index] = child
+			claimed[child.gotIndex] = true
+		}
+	}
+	sort.SliceStable(inserted, func(i, j int) bool {
+		return inserted[i].Path.Last().Index < inserted[j].Path.Last().Index
+	})
+	// insertBefore writes the elements inserted in rv2 before index j
+	insertBefore := func(j int) {
+		for len(inserted) > 0 && inserted[0].Path.Last().Index < j {
+			ins := inserted[0]
+			sw.writeEntry(ins, reflect.Value{}, ins.Got, ins.Path, "", ",")
+			inserted = inserted[1:]
+		}
+	}
+
+	sw.open(rv1, prefix)
+	next := 0
+	for i := 0; i < rv1.Len(); i++ {
+		elemPath := path.withIndex(i)
+		if child, ok := deleted[i]; ok {
+			sw.writeEntry(child, rv1.Index(i), reflect.Value{}, elemPath, "", ",")
+			continue
+		}
+		child, ok := changed[i]
+		j := -1
+		switch {
+		case ok:
+			j = child.gotIndex
+		default:
+			// Elements found equal are paired in order
+			for next < rv2.Len() && claimed[next] {
+				next++
+			}
+			if next < rv2.Len() {
+				j = next
+				next++
+			}
+		}
+		if j < 0 {
+			sw.writeEntry(nil, rv1.Index(i), reflect.Value{}, elemPath, "", ",")
+			continue
+		}
+		insertBefore(j)
+		sw.writeEntry(child, rv1.Index(i), rv2.Index(j), elemPath, "", ",")
+	}
+	insertBefore(rv2.Len())
+	sw.close(suffix)
+}
+
+// open writes the first line of a struct, map, slice or array of the type of
+// rv, prefixed with prefix, and indents the lines that follow.
+func (sw *sideBySideWriter) open(rv reflect.Value, prefix string) {
+	line := sw.indent() + prefix + rv.Type().String() + "{"
+	sw.rows = append(sw.rows, sideBySideRow{left: line, right: line, marker: bothMarker})
+	sw.level++
+}
+
+// close writes the last line of what open() began, suffixed with suffix.
+func (sw *sideBySideWriter) close(suffix string) {
+	sw.level--
+	line := sw.indent() + "}" + suffix
+	sw.rows = append(sw.rows, sideBySideRow{left: line, right: line, marker: bothMarker})
+}
+
+// writeLines writes rows pairing the left and right lines, with marker
+// between them where both have a line, or else the marker for the side that
+// has one if they differ.
+func (sw *sideBySideWriter) writeLines(left, right []string, marker string) {
+	for k := 0; k < max(len(left), len(right)); k++ {
+		row := sideBySideRow{marker: marker}
+		if k < len(left) {
+			row.left = left[k]
+		}
+		if k < len(right) {
+			row.right = right[k]
+		}
+		switch {
+		case marker == bothMarker:
+		case k >= len(right):
+			row.marker = leftMarker
+		case k >= len(left):
+			row.marker = rightMarker
+		}
+		sw.rows = append(sw.rows, row)
+	}
+}
+
+// pretty returns the lines of rv, found at path, pretty printed at the current
+// level, with the first prefixed by the indent and prefix and the last suffixed
+// with suffix, or nil if rv is not valid.
+func (sw *sideBySideWriter) pretty(rv reflect.Value, path Path, prefix, suffix string) (lines []string) {
+	if !rv.IsValid() {
+		goto end
+	}
+	lines = strings.Split(sw.prettyValue(rv, path), "\n")
+	lines[0] = sw.indent() + prefix + lines[0]
+	lines[len(lines)-1] += suffix
+end:
+	return lines
+}
+
+// isCompared returns false for the kinds of values whose contents are not
+// compared, funcs and unsafe pointers, so they are left out where they are
+// not found to differ.
+func isCompared(kind reflect.Kind) bool {
+	return kind != reflect.Func && kind != reflect.UnsafePointer
+}
+
+// prettyValue returns rv, found at path, printed as in the output of
+// CompareObjects(), with one field, element or map entry per line, each
+// indented by LevelIndent per level beyond the current one. Fields tagged
+// `diff:"-"`, values ignored, and funcs and unsafe pointers in fields are left
+// out.
+func (o *ObjectComparator) prettyValue(rv reflect.Value, path Path) string {
+	pw := prettyWriter{
+		ObjectComparator: o,
+		tracker:          NewTracker(),
+	}
+	pw.write(rv, path)
+	return pw.sb.String()
+}
+
+// prettyWriter writes values for prettyValue().
+type prettyWriter struct {
+	*ObjectComparator
+	sb      strings.Builder
+	tracker *Tracker
+}
+
+// write writes rv, found at path.
+func (pw *prettyWriter) write(rv reflect.Value, path Path) {
+	var handled bool
+
+	if !rv.IsValid() {
+		pw.sb.WriteString("nil")
+		return
+	}
+	seen, id := pw.tracker.Push(&rv)
+	if seen && isReference(rv.Kind()) {
+		pw.sb.WriteString("<recursion>")
+		return
+	}
+	defer pw.tracker.Pop(id)
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			pw.sb.WriteString("nil")
+			break
+		}
+		pw.sb.WriteByte('*')
+		pw.write(rv.Elem(), path)
+	case reflect.Interface:
+		if rv.IsNil() {
+			pw.sb.WriteString("nil")
+			break
+		}
+		pw.write(rv.Elem(), path)
+	case reflect.Struct:
+		_, handled = pw.customDiff(&rv, &rv, path)
+		if handled {
+			pw.sb.WriteString(opaqueString(rv))
+			break
+		}
+		pw.writeStruct(rv, path)
+	case reflect.Map:
+		var keys []reflect.Value
+		for _, key := range SortedMapKeys(&rv) {
+			val := rv.MapIndex(key)
+			if !pw.ignored(path.withKey(key), &val, &val) {
+				keys = append(keys, key)
+			}
+		}
+		pw.writeEntries(rv.Type().String(), len(keys), func(k int) (string, reflect.Value, Path) {
+			return NewReflector(keys[k]).String() + ":", rv.MapIndex(keys[k]), path.withKey(keys[k])
+		})
+	case reflect.Slice, reflect.Array:
+		if isBytesType(rv.Type()) {
+			pw.writeBytes(rv)
+			break
+		}
+		pw.writeEntries(rv.Type().String(), rv.Len(), func(k int) (string, reflect.Value, Path) {
+			return "", rv.Index(k), path.withIndex(k)
+		})
+	default:
+		pw.sb.WriteString(NewReflector(rv).String())
+	}
+}
+
+// writeBytes writes a slice or array of bytes on one line, quoted if it is
+// text or else in hex.
+func (pw *prettyWriter) writeBytes(rv reflect.Value) {
+	b := bytesOf(rv)
+	if isText(b) {
+		pw.sb.WriteString(fmt.Sprintf("%s(%q)", rv.Type(), b))
+		return
+	}
+	pw.sb.WriteString(fmt.Sprintf("%s{% x}", rv.Type(), b))
+}
+
+// writeStruct writes the fields of struct rv, found at path.
+func (pw *prettyWriter) writeStruct(rv reflect.Value, path Path) {
+	type field struct {
+		tag  fieldTag
+		path Path
+		rv   reflect.Value
+	}
+	var fields []field
+
+	for i, tag := range structTags(rv.Type()) {
+		fld := rv.Field(i)
+		fldPath := path.withField(tag.name, i)
+		if tag.skip || !isCompared(fld.Kind()) || pw.ignored(fldPath, &fld, &fld) {
+			continue
+		}
+		fields = append(fields, field{tag: tag, path: fldPath, rv: fld})
+	}
+	pw.writeEntries(rv.Type().String(), len(fields), func(k int) (string, reflect.Value, Path) {
+		fld := fields[k]
+		if !fld.tag.redact {
+			return fld.tag.name + ":", fld.rv, fld.path
+		}
+		return fld.tag.name + ":" + Redacted, reflect.Value{}, nil
+	})
+}
+
+// writeEntries writes n entries of a value of type typ between braces, one per
+// line, where entry returns the prefix, value and path of the k'th entry. An
+// invalid value writes only its prefix.
+func (pw *prettyWriter) writeEntries(typ string, n int, entry func(k int) (prefix string, rv reflect.Value, path Path)) {
+	pw.sb.WriteString(typ)
+	if n == 0 {
+		pw.sb.WriteString("{}")
+		return
+	}
+	pw.sb.WriteString("{\n")
+	pw.level++
+	for k := 0; k < n; k++ {
+		prefix, rv, path := entry(k)
+		pw.sb.WriteString(pw.indent())
+		pw.sb.WriteString(prefix)
+		if rv.IsValid() {
+			pw.write(rv, path)
+		}
+		pw.sb.WriteString(",\n")
+	}
+	pw.level--
+	pw.sb.WriteString(pw.indent())
+	pw.sb.WriteByte('}')
+}
@@ -0,0 +1,275 @@
+package diffator_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareStringsSideBySide(t *testing.T) {
+	tests := []struct {
+		name     string
+		s1       string
+		s2       string
+		opts     *diffator.StringOpts
+		wantDiff string
+	}{
+		{
+			name:     "Equal",
+			s1:       "a\nb\n",
+			s2:       "a\nb\n",
+			wantDiff: "",
+		},
+		{
+			name: "Changed, deleted and inserted lines",
+			s1:   "host = localhost\nport = 80\nuser = admin\nlog = info\n",
+			s2:   "host = localhost\nport = 8080\nlog = info\ntls = on\n",
+			opts: &diffator.StringOpts{Width: diffator.Int(43)},
+			wantDiff: "" +
+				"host = localhost       host = localhost\n" +
+				"port = 80            | port = 8080\n" +
+				"user = admin         <\n" +
+				"log = info             log = info\n" +
+				"                     > tls = on",
+		},
+		{
+			name: "Wrapped lines",
+			s1:   "short\nkeep\n",
+			s2:   "a line too long for its column\nkeep\n",
+			opts: &diffator.StringOpts{Width: diffator.Int(23)},
+			wantDiff: "" +
+				"short      | a line too\n" +
+				"           |  long for\n" +
+				"           | its column\n" +
+				"keep         keep",
+		},
+		{
+			name: "Tabs",
+			s1:   "a\tb\n",
+			s2:   "a\tc\n",
+			opts: &diffator.StringOpts{Width: diffator.Int(43)},
+			wantDiff: "" +
+				"a       b            | a       c",
+		},
+		{
+			name: "Wide and combining runes",
+			s1:   "名前 = 太郎\ncafe\u0301 🙂\n",
+			s2:   "名前 = 花子\ncafe\u0301 🙃\n",
+			opts: &diffator.StringOpts{Width: diffator.Int(33)},
+			wantDiff: "" +
+				"名前 = 太郎     | 名前 = 花子\n" +
+				"cafe\u0301 🙂         | cafe\u0301 🙃",
+		},
+		{
+			name: "Wrapped wide runes",
+			s1:   "short\n",
+			s2:   "日本語のテキストです\n",
+			opts: &diffator.StringOpts{Width: diffator.Int(24)},
+			wantDiff: "" +
+				"short      | 日本語のテ\n" +
+				"           | キストです",
+		},
+		{
+			name: "Context lines",
+			s1:   "1\n2\n3\n4\n5\n6\n7\n",
+			s2:   "1\n2\n3\nfour\n5\n6\n7\n",
+			opts: &diffator.StringOpts{Width: diffator.Int(23), ContextLines: diffator.Int(1)},
+			wantDiff: "" +
+				"...\n" +
+				"3            3\n" +
+				"4          | four\n" +
+				"5            5\n" +
+				"...",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if opts == nil {
+				opts = &diffator.StringOpts{}
+			}
+			opts.SideBySide = diffator.Bool(true)
+			assert.Equal(t, tt.wantDiff, diffator.CompareStrings(tt.s1, tt.s2, opts))
+		})
+	}
+}
+
+type SideBySideConfig struct {
+	Name     string
+	Port     int
+	Tags     []string
+	Env      map[string]string
+	Owner    *PatchOwner
+	Password string `diff:"redact"`
+	Internal string `diff:"-"`
+}
+
+func newSideBySideConfig() *SideBySideConfig {
+	return &SideBySideConfig{
+		Name:     "web",
+		Port:     80,
+		Tags:     []string{"a", "b"},
+		Env:      map[string]string{"A": "1"},
+		Owner:    &PatchOwner{Email: "a@x"},
+		Password: "secret",
+		Internal: "x",
+	}
+}
+
+func TestCompareObjectsSideBySide(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   func(c *SideBySideConfig)
+		opts     *diffator.ObjectOpts
+		wantDiff []string
+	}{
+		{
+			name:   "Equal",
+			change: func(c *SideBySideConfig) { c.Internal = "y" },
+		},
+		{
+			name: "Changed fields and elements",
+			change: func(c *SideBySideConfig) {
+				c.Port = 8080
+				c.Tags = append(c.Tags, "c")
+				c.Password = "changed"
+			},
+			opts: &diffator.ObjectOpts{Width: diffator.Int(71)},
+			wantDiff: []string{
+				"*diffator_test.SideBySideConfig{     *diffator_test.SideBySideConfig{",
+				`  Name:"web",                          Name:"web",`,
+				"  Port:80,                         |   Port:8080,",
+				"  Tags:[]string{                       Tags:[]string{",
+				`    "a",                                 "a",`,
+				`    "b",                                 "b",`,
+				`                                   >     "c",`,
+				"  },                                   },",
+				"  Env:map[string]string{               Env:map[string]string{",
+				`    "A":"1",                             "A":"1",`,
+				"  },                                   },",
+				"  Owner:*diffator_test.PatchOwner{     Owner:*diffator_test.PatchOwner{",
+				`    Email:"a@x",                         Email:"a@x",`,
+				"  },                                   },",
+				"  Password:<redacted>,             |   Password:<redacted> (changed),",
+				"}                                    }",
+			},
+		},
+		{
+			name:   "LevelIndent, PrettyPrint and context lines",
+			change: func(c *SideBySideConfig) { c.Env["A"] = "2" },
+			opts: &diffator.ObjectOpts{
+				Width:        diffator.Int(43),
+				LevelIndent:  diffator.String("\t"),
+				PrettyPrint:  diffator.Bool(true),
+				OutputFormat: diffator.String("Diff:\n%s"),
+				StringOpts:   &diffator.StringOpts{ContextLines: diffator.Int(1)},
+			},
+			wantDiff: []string{
+				"",
+				"Diff:",
+				"...",
+				"        Env:map[stri           Env:map[stri",
+				"ng]string{             ng]string{",
+				`                "A": |                 "A":`,
+				`"1",                 | "2",`,
+				"        },                     },",
+				"...",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if opts == nil {
+				opts = &diffator.ObjectOpts{}
+			}
+			opts.SideBySide = diffator.Bool(true)
+			c1, c2 := newSideBySideConfig(), newSideBySideConfig()
+			tt.change(c2)
+			assert.Equal(t, strings.Join(tt.wantDiff, "\n"), diffator.CompareObjects(c1, c2, opts))
+		})
+	}
+}
+
+type SideBySideRules struct {
+	At    time.Time
+	Score float64
+	Hook  func()
+	Ptr   unsafe.Pointer
+	Tags  []string
+	Meta  map[string]int
+}
+
+func TestCompareObjectsSideBySideRules(t *testing.T) {
+	at := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	n := 1
+	v1 := SideBySideRules{At: at, Score: math.NaN(), Hook: func() {}, Ptr: unsafe.Pointer(&n),
+		Tags: []string{"a", "b", "c"}, Meta: map[string]int{"a": 1, "b": 2},
+	}
+	v2 := SideBySideRules{At: at.In(time.FixedZone("EST", -5*60*60)), Score: math.NaN(), Hook: func() {}, Ptr: unsafe.Pointer(&n),
+		Tags: []string{"x", "a", "c", "d"}, Meta: map[string]int{"b": 3, "c": 4},
+	}
+	tests := []struct {
+		name     string
+		opts     *diffator.ObjectOpts
+		wantDiff []string
+	}{
+		{
+			name: "Marked as compared",
+			wantDiff: []string{
+				"diffator_test.SideBySideRules{                diffator_test.SideBySideRules{",
+				"  At:2024-01-01 12:00:00 +0000 UTC,             At:2024-01-01 07:00:00 -0500 EST,",
+				"  Score:NaN,                                |   Score:NaN,",
+				"  Tags:[]string{                                Tags:[]string{",
+				`                                            >     "x",`,
+				`    "a",                                          "a",`,
+				`    "b",                                    <`,
+				`    "c",                                          "c",`,
+				`                                            >     "d",`,
+				"  },                                            },",
+				"  Meta:map[string]int{                          Meta:map[string]int{",
+				`    "a":1,                                  <`,
+				`    "b":2,                                  |     "b":3,`,
+				`                                            >     "c":4,`,
+				"  },                                            },",
+				"}                                             }",
+			},
+		},
+		{
+			name: "NaNEqual and ignored",
+			opts: &diffator.ObjectOpts{
+				NaNEqual: true,
+				Ignore:   []diffator.IgnoreRule{diffator.IgnorePath("Meta")},
+			},
+			wantDiff: []string{
+				"diffator_test.SideBySideRules{                diffator_test.SideBySideRules{",
+				"  At:2024-01-01 12:00:00 +0000 UTC,             At:2024-01-01 07:00:00 -0500 EST,",
+				"  Score:NaN,                                    Score:NaN,",
+				"  Tags:[]string{                                Tags:[]string{",
+				`                                            >     "x",`,
+				`    "a",                                          "a",`,
+				`    "b",                                    <`,
+				`    "c",                                          "c",`,
+				`                                            >     "d",`,
+				"  },                                            },",
+				"}                                             }",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			if opts == nil {
+				opts = &diffator.ObjectOpts{}
+			}
+			opts.SideBySide = diffator.Bool(true)
+			opts.Width = diffator.Int(90)
+			assert.Equal(t, strings.Join(tt.wantDiff, "\n"), diffator.CompareObjects(v1, v2, opts))
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package diffator_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareObjectsSliceAlignment(t *testing.T) {
+	hundred := make([]int, 100)
+	for i := range hundred {
+		hundred[i] = i
+	}
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name:     "Inserted at front",
+			v1:       hundred,
+			v2:       append([]int{-1}, hundred...),
+			wantDiff: "[]int{[0]<inserted:-1>,}",
+		},
+		{
+			name:     "Deleted from middle",
+			v1:       []string{"a", "b", "c", "d"},
+			v2:       []string{"a", "c", "d"},
+			wantDiff: `[]string{[1]<deleted:"b">,}`,
+		},
+		{
+			name:     "Changed, inserted and deleted",
+			v1:       []string{"a", "b", "c", "d", "e"},
+			v2:       []string{"x", "a", "B", "c", "e"},
+			wantDiff: `[]string{[0]<inserted:"x">,[1](b!=B),[3]<deleted:"d">,}`,
+		},
+		{
+			name: "Changed struct shows its fields",
+			v1: []TestStruct{
+				{Int: 1, String: "one"},
+				{Int: 2, String: "two"},
+				{Int: 3, String: "three"},
+			},
+			v2: []TestStruct{
+				{Int: 0, String: "zero"},
+				{Int: 1, String: "one"},
+				{Int: 2, String: "TWO"},
+				{Int: 3, String: "three"},
+			},
+			wantDiff: `[]diffator_test.TestStruct{[0]<inserted:diffator_test.TestStruct{Int:0,String:"zero",}>,[1]diffator_test.TestStruct{String:(two!=TWO),},}`,
+		},
+		{
+			name:     "Array",
+			v1:       [4]int{1, 2, 3, 4},
+			v2:       [4]int{0, 1, 2, 3},
+			wantDiff: "[4]int[0]<inserted:0>,[3]<deleted:4>,",
+		},
+		{
+			name:     "Equal by comparison rules",
+			v1:       []UnorderedEvent{{ID: 1, Score: 1}, {ID: 2, Score: 2}, {ID: 3, Score: 3}},
+			v2:       []UnorderedEvent{{ID: 1, Score: 1.0001}, {ID: 4}, {ID: 2, Score: 2.0001}, {ID: 3, Score: 3}},
+			opts:     &diffator.ObjectOpts{FloatAbsEpsilon: 0.001},
+			wantDiff: "[]diffator_test.UnorderedEvent{[1]<inserted:diffator_test.UnorderedEvent{ID:4,At:0,Score:0,}>,}",
+		},
+		{
+			name:     "Index mode",
+			v1:       []int{1, 2, 3},
+			v2:       []int{0, 1, 2, 3},
+			opts:     &diffator.ObjectOpts{SliceIndexMode: true},
+			wantDiff: "[]int{[0](1!=0),[1](2!=1),[2](3!=2),[3](<missing>!=3),}",
+		},
+		{
+			name:     "Pretty printed",
+			v1:       []int{1, 2, 3},
+			v2:       []int{1, 3, 4},
+			opts:     &diffator.ObjectOpts{PrettyPrint: diffator.Bool(true)},
+			wantDiff: "\n[]int{\n  [1]<deleted:2>,\n  [2]<inserted:4>,\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
+
+type BenchmarkRow struct {
+	ID    int
+	Name  string
+	Score float64
+	Tags  []string
+}
+
+// benchmarkRows returns n rows, and a copy of them changed by change.
+func benchmarkRows(n int, change func(i int, r *BenchmarkRow)) (rows1, rows2 []BenchmarkRow) {
+	for i := 0; i < n; i++ {
+		r := BenchmarkRow{ID: i, Name: fmt.Sprintf("row %d", i), Score: float64(i) / 4, Tags: []string{"a", "b"}}
+		rows1 = append(rows1, r)
+		r.Tags = []string{"a", "b"}
+		change(i, &r)
+		rows2 = append(rows2, r)
+	}
+	return rows1, rows2
+}
+
+// BenchmarkCompareObjectsSlices benchmarks aligning slices of structs, where
+// comparing two elements means comparing them deeply.
+func BenchmarkCompareObjectsSlices(b *testing.B) {
+	ignore := &diffator.ObjectOpts{Ignore: []diffator.IgnoreRule{diffator.IgnorePath("[*].Tags")}}
+	benchmarks := []struct {
+		name   string
+		n      int
+		change func(i int, r *BenchmarkRow)
+		opts   *diffator.ObjectOpts
+	}{
+		{name: "1000 equal", n: 1000, change: func(int, *BenchmarkRow) {}},
+		{name: "1000 one changed", n: 1000, change: func(i int, r *BenchmarkRow) {
+			if i == 500 {
+				r.Name = "changed"
+			}
+		}},
+		{name: "500 all changed", n: 500, change: func(_ int, r *BenchmarkRow) { r.Name += "!" }},
+		{name: "1000 disjoint", n: 1000, change: func(i int, r *BenchmarkRow) { r.ID = -i - 1; r.Name = "other" }},
+		{name: "500 all changed with ignore", n: 500, change: func(_ int, r *BenchmarkRow) { r.Name += "!" }, opts: ignore},
+		{name: "1000 disjoint with ignore", n: 1000, change: func(i int, r *BenchmarkRow) { r.ID = -i - 1; r.Name = "other" }, opts: ignore},
+	}
+	for _, bm := range benchmarks {
+		rows1, rows2 := benchmarkRows(bm.n, bm.change)
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				diffator.CompareObjectsResult(rows1, rows2, bm.opts)
+			}
+		})
+	}
+}
@@ -1,9 +1,8 @@
 package diffator
 
 import (
+	"strings"
 	"unicode/utf8"
-
-	"github.com/mikeschinkel/go-lib"
 )
 
 var _ Comparator = (*StringComparator)(nil)
@@ -14,6 +13,8 @@ type StringComparator struct {
 	s2  string
 	og1 string
 	og2 string
+	t1  tokenSeq
+	t2  tokenSeq
 }
 
 func NewStringComparator(s1, s2 string, opts *StringOpts) *StringComparator {
@@ -21,9 +22,14 @@ func NewStringComparator(s1, s2 string, opts *StringOpts) *StringComparator {
 		opts = &StringOpts{}
 	}
 	opts.SetDefaults()
+	t1, t2 := opts.tokenize(s1, s2)
 	return &StringComparator{
 		s1:   s1,
 		s2:   s2,
+		og1:  s1,
+		og2:  s2,
+		t1:   t1,
+		t2:   t2,
 		tree: newTree(opts),
 	}
 }
@@ -33,58 +39,67 @@ func (c *StringComparator) Opts() *StringOpts {
 }
 
 func (c *StringComparator) Compare() (s string) {
-	var ok bool
+	return c.compare().render()
+}
 
-	if s, ok = c.handleEmptyString(); !ok {
+// compare finds the differences between the comparator's strings, ready to be
+// rendered.
+func (c *StringComparator) compare() *StringComparator {
+	if _, ok := c.handleEmptyString(); !ok {
 		goto end
 	}
 	c = c.findPrefixes()
 	c = c.findSuffixes()
 	c = c.findInfixes()
 end:
-	return c.String()
+	return c
+}
+
+// render returns the comparison result in the output format selected by the
+// comparator's options.
+func (c *StringComparator) render() (s string) {
+	switch {
+	case c.opts.Unified.Value:
+		s = c.opts.renderUnified(c.segments())
+	case c.opts.SideBySide.Value:
+		s = c.opts.renderSideBySide(c.segments())
+	case c.opts.LineMode.Value:
+		s = c.opts.renderLines(c.segments(), c.eolDiffers())
+	default:
+		s = c.String()
+	}
+	return s
+}
+
+// eolDiffers returns true if only one of the original strings ends in a
+// newline, which is otherwise invisible when rendering lines.
+func (c *StringComparator) eolDiffers() bool {
+	return strings.HasSuffix(c.og1, "\n") != strings.HasSuffix(c.og2, "\n")
 }
 
 // findPrefixes finds the initial suffixes. This could be handled by logic in
 // findInfixes, but then the logic for trimming the prefixes to pad length
 // becomes much more complicated
 func (c *StringComparator) findPrefixes() *StringComparator {
-	n1, n2 := 0, 0
-	s1 := c.s1
-	s2 := c.s2
+	n := 0
+	t1 := c.t1
+	t2 := c.t2
 	prefix := newNode(c.opts)
-	for {
-		if len(s1) == 0 {
-			goto end
-		}
-		if len(s2) == 0 {
-			goto end
-		}
-		r1, sz1 := utf8.DecodeRuneInString(s1)
-		if r1 == utf8.RuneError {
-			lib.Panicf("ERROR: Attempting to retrieve last rune in '%s'", s1)
-		}
-		r2, sz2 := utf8.DecodeRuneInString(s2)
-		if r2 == utf8.RuneError {
-			lib.Panicf("ERROR: Attempting to retrieve last rune in '%s'", s2)
+	for n < t1.len() && n < t2.len() {
+		if t1.ids[n] != t2.ids[n] {
+			break
 		}
-		if r1 != r2 {
-			goto end
-		}
-		prefix.AddBoth(string(r1))
-		s1 = s1[sz1:]
-		n1 += sz1
-		s2 = s2[sz2:]
-		n2 += sz2
+		n++
 	}
-end:
-	c.s1 = s1
-	c.s2 = s2
+	prefix.AddBoth(t1.slice(0, n).String())
+	c.t1 = t1.slice(n, t1.len())
+	c.t2 = t2.slice(n, t2.len())
 
 	pad := c.opts.MatchingPadLen.Value
-	// Trim the prefix if longer than the pad amount.
-	if pad > 0 && len(prefix.both) > pad {
-		prefix.both = prefix.both[len(prefix.both)-pad:]
+	// Trim the prefix if longer than the pad amount. Line mode shows context
+	// lines instead.
+	if pad > 0 && !c.opts.lineMode() {
+		prefix.both = lastRunes(prefix.both, pad)
 	}
 
 	c.prefix = prefix
@@ -95,42 +110,25 @@ end:
 // findInfixes, but then the logic for trimming the suffixes to pad length
 // becomes much more complicated
 func (c *StringComparator) findSuffixes() *StringComparator {
-	n1, n2 := 0, 0
-	s1 := c.s1
-	s2 := c.s2
+	n := 0
+	t1 := c.t1
+	t2 := c.t2
 	suffix := newNode(c.opts)
-	for {
-		if len(s1) == 0 {
-			goto end
-		}
-		if len(s2) == 0 {
-			goto end
-		}
-		r1, sz1 := utf8.DecodeLastRuneInString(s1)
-		if r1 == utf8.RuneError {
-			panicf("ERROR: Attempting to retrieve last rune in '%s'", s1)
-		}
-		r2, sz2 := utf8.DecodeLastRuneInString(s2)
-		if r2 == utf8.RuneError {
-			panicf("ERROR: Attempting to retrieve last rune in '%s'", s2)
-		}
-		if r1 != r2 {
-			goto end
+	for n < t1.len() && n < t2.len() {
+		if t1.ids[t1.len()-n-1] != t2.ids[t2.len()-n-1] {
+			break
 		}
-		suffix.InsertBoth(string(r1))
-		s1 = s1[:len(s1)-sz1]
-		n1 += sz1
-		s2 = s2[:len(s2)-sz2]
-		n2 += sz2
+		n++
 	}
-end:
-	c.s1 = c.s1[:len(c.s1)-n1]
-	c.s2 = c.s2[:len(c.s2)-n2]
+	suffix.InsertBoth(t1.slice(t1.len()-n, t1.len()).String())
+	c.t1 = t1.slice(0, t1.len()-n)
+	c.t2 = t2.slice(0, t2.len()-n)
 
 	pad := c.opts.MatchingPadLen.Value
-	// Trim the prefix if longer than the pad amount.
-	if pad > 0 && len(suffix.both) > pad {
-		suffix.both = suffix.both[:pad]
+	// Trim the suffix if longer than the pad amount. Line mode shows context
+	// lines instead.
+	if pad > 0 && !c.opts.lineMode() {
+		suffix.both = firstRunes(suffix.both, pad)
 	}
 
 	c.suffix = suffix
@@ -138,7 +136,7 @@ end:
 }
 
 func (c *StringComparator) findInfixes() *StringComparator {
-	ft := c.opts.findInfixes(c.s1, c.s2)
+	ft := c.opts.findInfixes(c.t1, c.t2)
 	c.infix = ft
 	return c
 }
@@ -164,3 +162,23 @@ func (c *StringComparator) handleEmptyString() (s string, ok bool) {
 end:
 	return s, ok
 }
+
+// firstRunes returns the first n runes of s.
+func firstRunes(s string, n int) string {
+	end := 0
+	for ; n > 0 && end < len(s); n-- {
+		_, sz := utf8.DecodeRuneInString(s[end:])
+		end += sz
+	}
+	return s[:end]
+}
+
+// lastRunes returns the last n runes of s.
+func lastRunes(s string, n int) string {
+	start := len(s)
+	for ; n > 0 && start > 0; n-- {
+		_, sz := utf8.DecodeLastRuneInString(s[:start])
+		start -= sz
+	}
+	return s[start:]
+}
@@ -1,37 +1,168 @@
 package diffator
 
-import (
-	"strings"
-)
-
 type StringOpts struct {
 	MatchingPadLen  *IntValue
 	MinSubstrLen    *IntValue
 	LeftRightFormat *StringValue
+	// LineMode compares strings line-by-line and renders changed lines with
+	// `-` and `+` markers instead of inline with LeftRightFormat.
+	LineMode *BoolValue
+	// ContextLines is the number of unchanged lines shown around changed lines
+	// in LineMode, Unified or SideBySide. Use -1 to show all unchanged lines.
+	ContextLines *IntValue
+	// Unified compares strings line-by-line like LineMode but renders the
+	// result as a standard unified diff (`diff -u`) suitable for `patch`.
+	Unified *BoolValue
+	// FromFile and ToFile name the want and got strings in the `---` and `+++`
+	// headers of a Unified diff.
+	FromFile *StringValue
+	ToFile   *StringValue
+	// SideBySide compares strings line-by-line like LineMode but renders the
+	// want and got lines in two columns, with a gutter between them marking
+	// lines changed `|`, deleted `<` or inserted `>`.
+	SideBySide *BoolValue
+	// Width is the width in columns of SideBySide output. Lines too long for
+	// their column are wrapped.
+	Width *IntValue
+	// Tokenizer splits strings into the units that are compared, e.g.
+	// WordTokenizer. If nil strings are compared rune-by-rune as with
+	// RuneTokenizer. It is ignored in LineMode, Unified and SideBySide.
+	Tokenizer func(s string) []string
+	// Color enables styling the want and got sides of each difference and the
+	// matching context, using Styler.
+	Color ColorMode
+	// Styler styles the output when Color is enabled; ANSIColors if nil.
+	Styler Styler
+
+	style Styler
 }
 
 // findInfixes finds the strings and substrings after prefixes and suffixes are
 // found. It creates a down-growth tree structure where differing prefixes and
 // suffixes are found and common values stored in infix property of the `node`
 // struct.
-func (opts *StringOpts) findInfixes(s1, s2 string) (ifx fixer) {
+//
+// Large inputs are first compared line-by-line, or word-by-word within long
+// lines, and only the lines that differ are then compared token-by-token. That
+// keeps the work for inputs with many differences close to linear, at the cost
+// of not always finding the very longest common substring.
+func (opts *StringOpts) findInfixes(t1, t2 tokenSeq) (ifx fixer) {
+	var l1, l2 lineSeq
+	var in interner
+
+	if opts.lineMode() {
+		goto exact
+	}
+	if !t1.coarse && t1.len()+t2.len() <= maxExactTokens {
+		goto exact
+	}
+	switch {
+	case t1.coarse:
+		// The tokens are already lines
+		l1 = lineSeq{tokenSeq: t1, tokens: t1}
+		l2 = lineSeq{tokenSeq: t2, tokens: t2}
+	default:
+		in = make(interner)
+		l1 = newLineSeq(t1, in)
+		l2 = newLineSeq(t2, in)
+	}
+	if l1.len() < 2 && l2.len() < 2 {
+		ifx = opts.findGapInfixes(t1, t2)
+		goto end
+	}
+	ifx = opts.findLineInfixes(l1, l2, 0, l1.len(), 0, l2.len(), matchLines(l1.ids, l2.ids))
+	goto end
+exact:
+	ifx = opts.findExactInfixes(t1, t2)
+end:
+	return ifx
+}
+
+// findLineInfixes builds a balanced tree from runs of lines common to l1 and
+// l2, as found by matchLines(), using findGapInfixes for the lines between
+// those runs. Lines lo1 through hi1-1 of l1 and lo2 through hi2-1 of l2 are
+// considered, and runs holds the common runs found within them.
+func (opts *StringOpts) findLineInfixes(l1, l2 lineSeq, lo1, hi1, lo2, hi2 int, runs []lineRun) (ifx fixer) {
+	var t *tree
+	var run lineRun
+	var mid int
+
+	if len(runs) == 0 {
+		ifx = opts.findGapInfixes(l1.tokenRange(lo1, hi1), l2.tokenRange(lo2, hi2))
+		goto end
+	}
+	mid = len(runs) / 2
+	run = runs[mid]
+	t = newTree(opts)
+	t.prefix = opts.findLineInfixes(l1, l2, lo1, run.pos1, lo2, run.pos2, runs[:mid])
+	t.infix.(*node).AddBoth(l1.tokenRange(run.pos1, run.pos1+run.length).String())
+	t.suffix = opts.findLineInfixes(l1, l2,
+		run.pos1+run.length, hi1,
+		run.pos2+run.length, hi2,
+		runs[mid+1:],
+	)
+	ifx = t
+end:
+	return ifx
+}
+
+// findGapInfixes compares the tokens of a large input that are left between
+// the lines common to both strings, after splitting any words back into runes.
+// Their common prefix and suffix are kept, and if what remains is still too
+// large to compare exactly it is reported as a single difference.
+func (opts *StringOpts) findGapInfixes(t1, t2 tokenSeq) (ifx fixer) {
 	var t *tree
-	var pos2 int
+	var pre, suf int
 
-	ss, pos1 := longestCommonSubstr(s1, s2)
-	pos2 = strings.Index(s2, ss)
-	switch opts.hasCommonSubstr(ss) {
+	if t1.coarse {
+		t1 = newRuneSeq(t1.String())
+		t2 = newRuneSeq(t2.String())
+	}
+	for pre < t1.len() && pre < t2.len() && t1.ids[pre] == t2.ids[pre] {
+		pre++
+	}
+	for suf < t1.len()-pre && suf < t2.len()-pre &&
+		t1.ids[t1.len()-suf-1] == t2.ids[t2.len()-suf-1] {
+		suf++
+	}
+	t = newTree(opts)
+	t.prefix.(*node).AddBoth(t1.slice(0, pre).String())
+	t.suffix.(*node).AddBoth(t1.slice(t1.len()-suf, t1.len()).String())
+	t1 = t1.slice(pre, t1.len()-suf)
+	t2 = t2.slice(pre, t2.len()-suf)
+	if t1.len()+t2.len() <= maxExactTokens {
+		t.infix = opts.findExactInfixes(t1, t2)
+		goto end
+	}
+	t.infix.(*node).AddLeft(t1.String())
+	t.infix.(*node).AddRight(t2.String())
+end:
+	ifx = t
+	return ifx
+}
+
+// findExactInfixes recursively splits t1 and t2 around their longest common
+// run of tokens.
+func (opts *StringOpts) findExactInfixes(t1, t2 tokenSeq) (ifx fixer) {
+	var t *tree
+
+	pos1, pos2, length := longestCommonSubstr(t1.ids, t2.ids)
+	common := t1.slice(pos1, pos1+length)
+	switch opts.hasCommonTokens(common) {
 	case true:
 		//goland:noinspection GoAssignmentToReceiver
 		t = newTree(opts)
-		t.prefix = opts.findInfixes(s1[:pos1], s2[:pos2])
-		t.infix.(*node).AddBoth(ss)
-		t.suffix = opts.findInfixes(s1[len(ss)+pos1:], s2[len(ss)+pos2:])
+		t.prefix = opts.findExactInfixes(t1.slice(0, pos1), t2.slice(0, pos2))
+		t.infix.(*node).AddBoth(common.String())
+		t.suffix = opts.findExactInfixes(
+			t1.slice(length+pos1, t1.len()),
+			t2.slice(length+pos2, t2.len()),
+		)
 		ifx = t
 	case false:
 		n := newNode(opts)
-		n.AddLeft(s1)
-		n.AddRight(s2)
+		n.AddLeft(t1.String())
+		n.AddRight(t2.String())
 		ifx = n
 		goto end
 	}
@@ -49,6 +180,75 @@ func (opts *StringOpts) SetDefaults() {
 	if opts.MatchingPadLen == nil {
 		opts.MatchingPadLen = Int(0)
 	}
+	if opts.LineMode == nil {
+		opts.LineMode = Bool(false)
+	}
+	if opts.ContextLines == nil {
+		opts.ContextLines = Int(ContextLines)
+	}
+	if opts.Unified == nil {
+		opts.Unified = Bool(false)
+	}
+	if opts.SideBySide == nil {
+		opts.SideBySide = Bool(false)
+	}
+	if opts.Width == nil {
+		opts.Width = Int(SideBySideWidth)
+	}
+	if opts.FromFile == nil {
+		opts.FromFile = String("want")
+	}
+	if opts.ToFile == nil {
+		opts.ToFile = String("got")
+	}
+	opts.style = resolveStyler(opts.Color, opts.Styler)
+}
+
+// lineMode returns true if strings are compared line-by-line.
+func (opts *StringOpts) lineMode() bool {
+	return opts.LineMode.Value || opts.Unified.Value || opts.SideBySide.Value
+}
+
+// tokenize splits s1 and s2 into the units compared by the comparator. Large
+// strings compared rune by rune are split into lines and words instead, to
+// spare them a token per rune, and findInfixes splits those that differ into
+// runes.
+func (opts *StringOpts) tokenize(s1, s2 string) (t1, t2 tokenSeq) {
+	var in interner
+	switch {
+	case opts.lineMode():
+		in = make(interner)
+		t1 = newTokenSeq(s1, LineTokenizer, in)
+		t2 = newTokenSeq(s2, LineTokenizer, in)
+	case opts.Tokenizer != nil:
+		in = make(interner)
+		t1 = newTokenSeq(s1, opts.Tokenizer, in)
+		t2 = newTokenSeq(s2, opts.Tokenizer, in)
+	case len(s1)+len(s2) > maxExactTokens:
+		in = make(interner)
+		t1 = newWordSeq(s1, in)
+		t2 = newWordSeq(s2, in)
+	default:
+		t1 = newRuneSeq(s1)
+		t2 = newRuneSeq(s2)
+	}
+	return t1, t2
+}
+
+// hasCommonTokens returns true if tokens are worth keeping as a common run
+// rather than being reported as part of a difference. In line mode any common
+// line qualifies, otherwise see hasCommonSubstr().
+func (opts *StringOpts) hasCommonTokens(tokens tokenSeq) (has bool) {
+	if tokens.len() == 0 {
+		goto end
+	}
+	if opts.lineMode() {
+		has = true
+		goto end
+	}
+	has = opts.hasCommonSubstr(tokens.String())
+end:
+	return has
 }
 
 // hasCommonSubstr returns true is a "common substring" — see `const
@@ -0,0 +1,142 @@
+package diffator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DiffTag is the struct tag key read to customize how fields are compared, e.g.
+// `diff:"name=Email,redact"`.
+const DiffTag = "diff"
+
+// Redacted is displayed in place of the values of fields tagged `redact`.
+const Redacted = "<redacted>"
+
+// fieldTag holds the options parsed from a field's `diff` tag.
+type fieldTag struct {
+	// skip leaves the field out of comparisons, for `diff:"-"`.
+	skip bool
+	// name is the field name used in paths and output, for `diff:"name=..."`,
+	// or else the field's own name.
+	name string
+	// tolerance is the largest difference allowed between floats in the field,
+	// for `diff:"tolerance=..."`.
+	tolerance float64
+	// unordered compares a slice or array field ignoring the order of its
+	// elements, for `diff:"unordered"`.
+	unordered bool
+	// redact masks the values of a field that differs, for `diff:"redact"`.
+	redact bool
+}
+
+// structTagsCache maps a struct's reflect.Type to its []fieldTag.
+var structTagsCache sync.Map
+
+// structTags returns the parsed `diff` tags of the fields of struct type rt,
+// indexed by field. Tags are parsed once per type, and malformed options are
+// ignored; see ValidateTags().
+func structTags(rt reflect.Type) []fieldTag {
+	if tags, ok := structTagsCache.Load(rt); ok {
+		return tags.([]fieldTag)
+	}
+	tags := make([]fieldTag, rt.NumField())
+	for i := range tags {
+		tags[i], _ = parseFieldTag(rt, rt.Field(i))
+	}
+	actual, _ := structTagsCache.LoadOrStore(rt, tags)
+	return actual.([]fieldTag)
+}
+
+// ValidateTags returns an error describing every malformed option in the `diff`
+// tags of the struct types of values, and of the struct types they contain via
+// fields, pointers, slices, arrays and maps, or nil if there are none. Values
+// may be reflect.Types. Comparisons ignore malformed options, so call it from a
+// test to catch mistakes in the tags of types a package owns.
+func ValidateTags(values ...any) error {
+	var errs []error
+	seen := make(map[reflect.Type]bool)
+	for _, v := range values {
+		rt, ok := v.(reflect.Type)
+		if !ok {
+			rt = reflect.TypeOf(v)
+		}
+		errs = appendTagErrors(errs, rt, seen)
+	}
+	return errors.Join(errs...)
+}
+
+// appendTagErrors appends the errors in the `diff` tags of the struct types in
+// rt to errs, skipping types already seen.
+func appendTagErrors(errs []error, rt reflect.Type, seen map[reflect.Type]bool) []error {
+	if rt == nil || seen[rt] {
+		return errs
+	}
+	seen[rt] = true
+	switch rt.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		errs = appendTagErrors(errs, rt.Elem(), seen)
+	case reflect.Map:
+		errs = appendTagErrors(errs, rt.Key(), seen)
+		errs = appendTagErrors(errs, rt.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < rt.NumField(); i++ {
+			fld := rt.Field(i)
+			_, err := parseFieldTag(rt, fld)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			errs = appendTagErrors(errs, fld.Type, seen)
+		}
+	}
+	return errs
+}
+
+// parseFieldTag parses the `diff` tag of field fld of struct type rt. Malformed
+// options are left out of tag and reported by err.
+func parseFieldTag(rt reflect.Type, fld reflect.StructField) (tag fieldTag, err error) {
+	var errs []error
+
+	tag.name = fld.Name
+	value, ok := fld.Tag.Lookup(DiffTag)
+	if !ok || value == "" {
+		goto end
+	}
+	if value == "-" {
+		tag.skip = true
+		goto end
+	}
+	for _, opt := range strings.Split(value, ",") {
+		key, arg, hasArg := strings.Cut(strings.TrimSpace(opt), "=")
+		switch {
+		case key == "name" && hasArg && arg != "":
+			tag.name = arg
+		case key == "tolerance" && hasArg:
+			tolerance, parseErr := strconv.ParseFloat(arg, 64)
+			if parseErr != nil || tolerance < 0 {
+				errs = append(errs, fmt.Errorf("invalid tolerance '%s' in `diff` tag of %s.%s", arg, rt, fld.Name))
+				break
+			}
+			tag.tolerance = tolerance
+		case key == "unordered" && !hasArg:
+			tag.unordered = true
+		case key == "redact" && !hasArg:
+			tag.redact = true
+		default:
+			errs = append(errs, fmt.Errorf("invalid option '%s' in `diff` tag of %s.%s", opt, rt, fld.Name))
+		}
+	}
+end:
+	return tag, errors.Join(errs...)
+}
+
+// redactedString returns rv as written by Reflector, with the values of any
+// struct fields within it tagged `diff:"redact"` masked.
+func redactedString(rv reflect.Value) string {
+	r := NewReflector(rv)
+	r.Redact = true
+	return r.String()
+}
@@ -0,0 +1,180 @@
+package diffator_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+type TaggedUser struct {
+	ID       int       `diff:"-"`
+	Mail     string    `diff:"name=Email"`
+	Score    float64   `diff:"tolerance=0.001"`
+	Scores   []float64 `diff:"tolerance=0.01"`
+	Roles    []string  `diff:"unordered"`
+	Password string    `diff:"redact"`
+	Secrets  []string  `diff:"redact"`
+	Groups   [][]int   `diff:"unordered"`
+}
+
+func TestCompareObjectsStructTags(t *testing.T) {
+	tests := []struct {
+		name       string
+		v1         *TaggedUser
+		v2         *TaggedUser
+		wantDiff   string
+		wantLeaves []string
+	}{
+		{
+			name: "Equal given tags",
+			v1: &TaggedUser{
+				ID:     1,
+				Score:  1.0,
+				Scores: []float64{1.0, 2.0},
+				Roles:  []string{"admin", "user", "user"},
+				Groups: [][]int{{1, 2}, {3}},
+			},
+			v2: &TaggedUser{
+				ID:     2,
+				Score:  1.0005,
+				Scores: []float64{1.005, 1.995},
+				Roles:  []string{"user", "admin", "user"},
+				Groups: [][]int{{3}, {1, 2}},
+			},
+		},
+		{
+			name:       "Renamed field",
+			v1:         &TaggedUser{Mail: "alice@example.com"},
+			v2:         &TaggedUser{Mail: "alice@example.org"},
+			wantDiff:   "*diffator_test.TaggedUser{Email:(alice@example.com!=alice@example.org),}",
+			wantLeaves: []string{"Email"},
+		},
+		{
+			name:       "Outside tolerance",
+			v1:         &TaggedUser{Score: 1.0, Scores: []float64{1.0}},
+			v2:         &TaggedUser{Score: 1.01, Scores: []float64{1.1}},
+			wantDiff:   "*diffator_test.TaggedUser{Score:(1!=1.01),Scores:[]float64{[0](1!=1.1),},}",
+			wantLeaves: []string{"Score", "Scores[0]"},
+		},
+		{
+			name:       "Unordered with missing elements",
+			v1:         &TaggedUser{Roles: []string{"admin", "user", "user"}},
+			v2:         &TaggedUser{Roles: []string{"guest", "user", "admin"}},
+			wantDiff:   "*diffator_test.TaggedUser{Roles:[]string{[2]<deleted:\"user\">,[0]<inserted:\"guest\">,},}",
+			wantLeaves: []string{"Roles[2]", "Roles[0]"},
+		},
+		{
+			name:       "Unordered applies only to the field itself",
+			v1:         &TaggedUser{Groups: [][]int{{1, 2}}},
+			v2:         &TaggedUser{Groups: [][]int{{2, 1}}},
+			wantDiff:   "*diffator_test.TaggedUser{Groups:[][]int{[0]<deleted:[]int{1,2,}>,[0]<inserted:[]int{2,1,}>,},}",
+			wantLeaves: []string{"Groups[0]", "Groups[0]"},
+		},
+		{
+			name:       "Redacted",
+			v1:         &TaggedUser{Password: "hunter2", Secrets: []string{"a", "b"}},
+			v2:         &TaggedUser{Password: "hunter3", Secrets: []string{"a"}},
+			wantDiff:   "*diffator_test.TaggedUser{Password:(<redacted>!=<redacted>),Secrets:(<redacted>!=<redacted>),}",
+			wantLeaves: []string{"Password", "Secrets"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, nil))
+			var leaves []string
+			for _, leaf := range diffator.CompareObjectsResult(tt.v1, tt.v2, nil).Leaves() {
+				leaves = append(leaves, leaf.Path.String())
+			}
+			assert.Equal(t, tt.wantLeaves, leaves)
+		})
+	}
+}
+
+type RedactedOwner struct {
+	Name     string
+	Password string `diff:"redact"`
+}
+
+type RedactedAccount struct {
+	Owner *RedactedOwner
+	Users []RedactedOwner
+	Any   any
+}
+
+func TestCompareObjectsRedactedWhole(t *testing.T) {
+	tests := []struct {
+		name     string
+		v1       RedactedAccount
+		v2       RedactedAccount
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name:     "Inserted element",
+			v1:       RedactedAccount{Users: []RedactedOwner{{Name: "a", Password: "s3cret"}}},
+			v2:       RedactedAccount{Users: []RedactedOwner{{Name: "a", Password: "s3cret"}, {Name: "c", Password: "topsecret"}}},
+			wantDiff: `diffator_test.RedactedAccount{Users:[]diffator_test.RedactedOwner{[1]<inserted:diffator_test.RedactedOwner{Name:"c",Password:<redacted>,}>,},}`,
+		},
+		{
+			name:     "Deleted element",
+			v1:       RedactedAccount{Users: []RedactedOwner{{Name: "a", Password: "s3cret"}}},
+			v2:       RedactedAccount{},
+			wantDiff: `diffator_test.RedactedAccount{Users:[]diffator_test.RedactedOwner{[0]<deleted:diffator_test.RedactedOwner{Name:"a",Password:<redacted>,}>,},}`,
+		},
+		{
+			name:     "Missing element by index",
+			v1:       RedactedAccount{Users: []RedactedOwner{{Name: "a", Password: "s3cret"}}},
+			v2:       RedactedAccount{},
+			opts:     &diffator.ObjectOpts{SliceIndexMode: true},
+			wantDiff: `diffator_test.RedactedAccount{Users:[]diffator_test.RedactedOwner{[0](diffator_test.RedactedOwner{Name:"a",Password:<redacted>,}!=<missing>),},}`,
+		},
+		{
+			name:     "Nil pointer",
+			v1:       RedactedAccount{},
+			v2:       RedactedAccount{Owner: &RedactedOwner{Name: "b", Password: "hunter2"}},
+			wantDiff: `diffator_test.RedactedAccount{Owner:(nil!=diffator_test.RedactedOwner{Name:"b",Password:<redacted>,}),}`,
+		},
+		{
+			name:     "Type mismatch",
+			v1:       RedactedAccount{Any: 1},
+			v2:       RedactedAccount{Any: RedactedOwner{Name: "b", Password: "hunter2"}},
+			wantDiff: `diffator_test.RedactedAccount{Any:<type-mismatch>:(1!=diffator_test.RedactedOwner{Name:"b",Password:<redacted>,}),}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
+
+func TestCompareObjectsStructTagsMalformed(t *testing.T) {
+	type badTolerance struct {
+		F float64 `diff:"tolerance=abc"`
+	}
+	type badOption struct {
+		S []string `diff:"unorderd,redact"`
+	}
+	type thirdParty struct {
+		Bad  *badTolerance
+		Opts map[string][]badOption
+	}
+	// Malformed options are ignored rather than failing comparisons of types
+	// the caller may not own
+	assert.Equal(t, "diffator_test.badTolerance{F:(1!=2),}",
+		diffator.CompareObjects(badTolerance{F: 1}, badTolerance{F: 2}, nil),
+	)
+	assert.Equal(t, "diffator_test.badOption{S:(<redacted>!=<redacted>),}",
+		diffator.CompareObjects(badOption{S: []string{"a"}}, badOption{S: []string{"b"}}, nil),
+	)
+
+	assert.NoError(t, diffator.ValidateTags(TaggedUser{}, &TaggedUser{}))
+	err := diffator.ValidateTags(&thirdParty{})
+	assert.EqualError(t, err, ""+
+		"invalid tolerance 'abc' in `diff` tag of diffator_test.badTolerance.F\n"+
+		"invalid option 'unorderd' in `diff` tag of diffator_test.badOption.S",
+	)
+	assert.Equal(t, err.Error(), diffator.ValidateTags(reflect.TypeOf(thirdParty{})).Error())
+}
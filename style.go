@@ -0,0 +1,119 @@
+package diffator
+
+import (
+	"os"
+)
+
+// ColorMode selects whether diffs are styled by a Styler, e.g. colored.
+type ColorMode int
+
+const (
+	// ColorNever renders diffs as plain text. It is the default.
+	ColorNever ColorMode = iota
+	// ColorAuto styles diffs only when stdout is a terminal, `NO_COLOR` is not
+	// set and `TERM` is not `dumb`.
+	ColorAuto
+	// ColorAlways styles diffs regardless of where they are written.
+	ColorAlways
+)
+
+// Styler styles the parts of a diff: the want side of each difference, the got
+// side, and the matching context around them.
+type Styler interface {
+	Want(s string) string
+	Got(s string) string
+	Context(s string) string
+}
+
+var _ Styler = ANSIStyler{}
+
+// ANSIStyler styles text with ANSI SGR escape sequences, e.g. "31" for red. An
+// empty code leaves the text as-is.
+type ANSIStyler struct {
+	WantCode    string
+	GotCode     string
+	ContextCode string
+}
+
+// ANSIColors renders want in red, got in green and context dimmed. It is the
+// default Styler.
+var ANSIColors = ANSIStyler{
+	WantCode:    "31",
+	GotCode:     "32",
+	ContextCode: "2",
+}
+
+// ANSIEmphasis renders want underlined and got in bold without using color,
+// for readers who cannot easily tell red from green.
+var ANSIEmphasis = ANSIStyler{
+	WantCode: "4",
+	GotCode:  "1",
+}
+
+func (st ANSIStyler) Want(s string) string {
+	return ansiWrap(st.WantCode, s)
+}
+
+func (st ANSIStyler) Got(s string) string {
+	return ansiWrap(st.GotCode, s)
+}
+
+func (st ANSIStyler) Context(s string) string {
+	return ansiWrap(st.ContextCode, s)
+}
+
+func ansiWrap(code, s string) string {
+	if code == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// plainStyler leaves text as-is; it is used when styling is disabled.
+type plainStyler struct{}
+
+func (plainStyler) Want(s string) string    { return s }
+func (plainStyler) Got(s string) string     { return s }
+func (plainStyler) Context(s string) string { return s }
+
+// resolveStyler returns the Styler to use for mode, defaulting to ANSIColors.
+func resolveStyler(mode ColorMode, st Styler) Styler {
+	if !colorEnabled(mode) {
+		return plainStyler{}
+	}
+	if st == nil {
+		st = ANSIColors
+	}
+	return st
+}
+
+func colorEnabled(mode ColorMode) (enabled bool) {
+	switch mode {
+	case ColorAlways:
+		enabled = true
+	case ColorAuto:
+		enabled = isColorTerminal()
+	}
+	return enabled
+}
+
+// isColorTerminal returns true if stdout is a terminal and the environment does
+// not ask for color to be disabled; see https://no-color.org.
+func isColorTerminal() (is bool) {
+	var fi os.FileInfo
+	var err error
+
+	if os.Getenv("NO_COLOR") != "" {
+		goto end
+	}
+	if os.Getenv("TERM") == "dumb" {
+		goto end
+	}
+	fi, err = os.Stdout.Stat()
+	if err != nil {
+		goto end
+	}
+	is = fi.Mode()&os.ModeCharDevice != 0
+end:
+	return is
+}
@@ -0,0 +1,87 @@
+package diffator_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+// bracketStyler marks want, got and context with brackets so tests can see
+// which parts were styled without reading escape sequences.
+type bracketStyler struct{}
+
+func (bracketStyler) Want(s string) string    { return "[-" + s + "-]" }
+func (bracketStyler) Got(s string) string     { return "{+" + s + "+}" }
+func (bracketStyler) Context(s string) string { return "~" + s + "~" }
+
+func TestStringColor(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *diffator.StringOpts
+		s1   string
+		s2   string
+		want string
+	}{
+		{
+			name: "Color off by default",
+			opts: &diffator.StringOpts{},
+			s1:   "ABCDEF",
+			s2:   "ABCDXYZ",
+			want: "ABCD<(EF/XYZ)>",
+		},
+		{
+			name: "ANSI colors",
+			opts: &diffator.StringOpts{Color: diffator.ColorAlways},
+			s1:   "ABCDEF",
+			s2:   "ABCDXYZ",
+			want: "\x1b[2mABCD\x1b[0m<(\x1b[31mEF\x1b[0m/\x1b[32mXYZ\x1b[0m)>",
+		},
+		{
+			name: "Custom styler",
+			opts: &diffator.StringOpts{Color: diffator.ColorAlways, Styler: bracketStyler{}},
+			s1:   "ABCDEF",
+			s2:   "ABCDXYZ",
+			want: "~ABCD~<([-EF-]/{+XYZ+})>",
+		},
+		{
+			name: "Emphasis without color",
+			opts: &diffator.StringOpts{Color: diffator.ColorAlways, Styler: diffator.ANSIEmphasis},
+			s1:   "ABCDEF",
+			s2:   "ABCDXYZ",
+			want: "ABCD<(\x1b[4mEF\x1b[0m/\x1b[1mXYZ\x1b[0m)>",
+		},
+		{
+			name: "Line mode",
+			opts: &diffator.StringOpts{
+				Color:    diffator.ColorAlways,
+				Styler:   bracketStyler{},
+				LineMode: diffator.Bool(true),
+			},
+			s1:   "one\ntwo\n",
+			s2:   "one\n2\n",
+			want: "~ one~\n[--two-]\n{++2+}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffator.CompareStrings(tt.s1, tt.s2, tt.opts))
+		})
+	}
+}
+
+func TestObjectColor(t *testing.T) {
+	got := diffator.CompareObjects(
+		map[string]int{"Foo": 1, "Bar": 2},
+		map[string]int{"Foo": 10, "Baz": 3},
+		&diffator.ObjectOpts{Color: diffator.ColorAlways, Styler: bracketStyler{}},
+	)
+	assert.Equal(t, "map[string]int{Bar:[-<missing:expected>-],Foo:([-1-]!={+10+}),Baz:{+<missing:actual>+},}", got)
+}
+
+func TestColorAuto(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	got := diffator.CompareStrings("ABCDEF", "ABCDXYZ", &diffator.StringOpts{Color: diffator.ColorAuto})
+	assert.False(t, strings.Contains(got, "\x1b["), "NO_COLOR should disable color: %q", got)
+}
@@ -0,0 +1,183 @@
+package diffator
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenSeq is a string split into tokens, each token interned as an int32 id
+// so tokens are compared as integers rather than as strings.
+type tokenSeq struct {
+	text   string  // The complete string; shared by all sub-sequences
+	offs   []int32 // Byte offset of each token in text, plus the end offset
+	ids    []int32
+	coarse bool // Tokens are lines or words, to be split into runes where they differ
+}
+
+func (ts tokenSeq) len() int {
+	return len(ts.ids)
+}
+
+func (ts tokenSeq) slice(i, j int) tokenSeq {
+	return tokenSeq{
+		text:   ts.text,
+		offs:   ts.offs[i : j+1],
+		ids:    ts.ids[i:j],
+		coarse: ts.coarse,
+	}
+}
+
+func (ts tokenSeq) token(i int) string {
+	return ts.text[ts.offs[i]:ts.offs[i+1]]
+}
+
+func (ts tokenSeq) String() string {
+	return ts.text[ts.offs[0]:ts.offs[len(ts.ids)]]
+}
+
+// interner assigns ids to distinct tokens.
+type interner map[string]int32
+
+func (in interner) id(tok string) int32 {
+	id, ok := in[tok]
+	if !ok {
+		id = int32(len(in))
+		in[tok] = id
+	}
+	return id
+}
+
+// newRuneSeq tokenizes s into runes, using each rune as its own id. This is
+// equivalent to RuneTokenizer but avoids allocating a string per rune, which
+// matters for large inputs.
+func newRuneSeq(s string) tokenSeq {
+	offs := make([]int32, 0, len(s)+1)
+	ids := make([]int32, 0, len(s))
+	for i := 0; i < len(s); {
+		r, sz := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && sz == 1 {
+			// Keep invalid bytes distinct from each other and from valid runes
+			r = -rune(s[i]) - 1
+		}
+		offs = append(offs, int32(i))
+		ids = append(ids, r)
+		i += sz
+	}
+	offs = append(offs, int32(len(s)))
+	return tokenSeq{text: s, offs: offs, ids: ids}
+}
+
+// newWordSeq tokenizes s into lines, as the units of a large input compared
+// rune by rune, so that it does not take a token per rune. Lines of more than
+// maxLineTokens runes are split as newLineSeq would split them, into words that
+// each end with the rune after them and every other rune on its own. Only the
+// tokens that differ are later split into runes.
+func newWordSeq(s string, in interner) tokenSeq {
+	n := 0
+	wordEnds(s, func(int) { n++ })
+	ts := tokenSeq{
+		text:   s,
+		offs:   make([]int32, 1, n+1),
+		ids:    make([]int32, 0, n),
+		coarse: true,
+	}
+	wordEnds(s, func(end int) {
+		start := ts.offs[len(ts.offs)-1]
+		ts.ids = append(ts.ids, in.id(s[start:end]))
+		ts.offs = append(ts.offs, int32(end))
+	})
+	return ts
+}
+
+// wordEnds calls yield with the end of each token of s found by newWordSeq.
+func wordEnds(s string, yield func(end int)) {
+	for start := 0; start < len(s); {
+		end := len(s)
+		if n := strings.IndexByte(s[start:], '\n'); n != -1 {
+			end = start + n + 1
+		}
+		if utf8.RuneCountInString(s[start:end]) > maxLineTokens {
+			for i := start; i < end; {
+				r, sz := utf8.DecodeRuneInString(s[i:])
+				i += sz
+				if !isWordRune(r) && i < end {
+					yield(i)
+				}
+			}
+		}
+		yield(end)
+		start = end
+	}
+}
+
+// newTokenSeq tokenizes s with tokenizer, interning the tokens with in. If the
+// tokens do not concatenate back to s then their concatenation is used as the
+// text instead.
+func newTokenSeq(s string, tokenizer func(string) []string, in interner) tokenSeq {
+	tokens := tokenizer(s)
+	offs := make([]int32, 1, len(tokens)+1)
+	ids := make([]int32, len(tokens))
+	n := 0
+	for i, tok := range tokens {
+		ids[i] = in.id(tok)
+		n += len(tok)
+		offs = append(offs, int32(n))
+	}
+	if joined := strings.Join(tokens, ""); joined != s {
+		s = joined
+	}
+	return tokenSeq{text: s, offs: offs, ids: ids}
+}
+
+// lineSeq groups the tokens of a tokenSeq into lines, where a line ends with
+// any token containing a newline. Lines of more than maxLineTokens tokens are
+// instead split after each token that does not end with a word rune, so that a
+// long line such as minified JSON is matched word by word. It is itself a
+// tokenSeq of those lines and words.
+type lineSeq struct {
+	tokenSeq
+	tokens tokenSeq // The tokens grouped into lines
+	starts []int32  // Index in tokens where each line starts, plus the end; nil if each token is a line
+}
+
+func newLineSeq(ts tokenSeq, in interner) lineSeq {
+	ls := lineSeq{
+		tokenSeq: tokenSeq{text: ts.text, offs: []int32{ts.offs[0]}},
+		tokens:   ts,
+		starts:   []int32{0},
+	}
+	lineStart := 0
+	for i := 0; i < ts.len(); i++ {
+		if i < ts.len()-1 && strings.IndexByte(ts.token(i), '\n') == -1 {
+			continue
+		}
+		if i+1-lineStart > maxLineTokens {
+			for j := lineStart; j < i; j++ {
+				r, _ := utf8.DecodeLastRuneInString(ts.token(j))
+				if !isWordRune(r) {
+					ls.add(ts, j+1, in)
+				}
+			}
+		}
+		ls.add(ts, i+1, in)
+		lineStart = i + 1
+	}
+	return ls
+}
+
+// add appends the tokens from the end of the last line up to token end of ts as
+// a line.
+func (ls *lineSeq) add(ts tokenSeq, end int, in interner) {
+	start := ls.offs[len(ls.offs)-1]
+	ls.ids = append(ls.ids, in.id(ts.text[start:ts.offs[end]]))
+	ls.offs = append(ls.offs, ts.offs[end])
+	ls.starts = append(ls.starts, int32(end))
+}
+
+// tokenRange returns the tokens that make up lines lo through hi-1.
+func (ls lineSeq) tokenRange(lo, hi int) tokenSeq {
+	if ls.starts == nil {
+		return ls.tokens.slice(lo, hi)
+	}
+	return ls.tokens.slice(int(ls.starts[lo]), int(ls.starts[hi]))
+}
@@ -0,0 +1,109 @@
+package diffator
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// RuneTokenizer splits s into one token per rune, which is the default for
+// `CompareStrings()`. Invalid UTF-8 bytes become single byte tokens so that no
+// input is lost.
+func RuneTokenizer(s string) (tokens []string) {
+	tokens = make([]string, 0, len(s))
+	for len(s) > 0 {
+		_, sz := utf8.DecodeRuneInString(s)
+		tokens = append(tokens, s[:sz])
+		s = s[sz:]
+	}
+	return tokens
+}
+
+// LineTokenizer splits s into one token per line, each token including its
+// trailing newline, so that joining the tokens reproduces s exactly.
+func LineTokenizer(s string) (tokens []string) {
+	for len(s) > 0 {
+		n := strings.IndexByte(s, '\n')
+		if n == -1 {
+			tokens = append(tokens, s)
+			break
+		}
+		tokens = append(tokens, s[:n+1])
+		s = s[n+1:]
+	}
+	return tokens
+}
+
+// WordTokenizer splits s on word boundaries, loosely following Unicode word
+// segmentation (UAX #29): runs of letters, digits and marks form a word, as do
+// letters joined by an apostrophe, period or colon (`it's`, `e.g`) and digits
+// joined by a comma or period (`1,000.5`). Each run of whitespace is a single
+// token, and each punctuation or symbol rune and each ideograph is a token of
+// its own.
+func WordTokenizer(s string) (tokens []string) {
+	start := 0
+	for start < len(s) {
+		r, sz := utf8.DecodeRuneInString(s[start:])
+		end := start + sz
+		switch {
+		case unicode.IsSpace(r):
+			for end < len(s) {
+				r, sz = utf8.DecodeRuneInString(s[end:])
+				if !unicode.IsSpace(r) {
+					break
+				}
+				end += sz
+			}
+		case isIdeograph(r):
+			// Each ideograph is a word by itself.
+		case isWordRune(r):
+			end = wordEnd(s, end, r)
+		}
+		tokens = append(tokens, s[start:end])
+		start = end
+	}
+	return tokens
+}
+
+// wordEnd returns the end of the word that starts with rune prev and continues
+// from position pos in s.
+func wordEnd(s string, pos int, prev rune) int {
+	for pos < len(s) {
+		r, sz := utf8.DecodeRuneInString(s[pos:])
+		if isWordRune(r) && !isIdeograph(r) {
+			prev = r
+			pos += sz
+			continue
+		}
+		next, nsz := utf8.DecodeRuneInString(s[pos+sz:])
+		if nsz == 0 || !joinsWord(prev, r, next) {
+			break
+		}
+		prev = next
+		pos += sz + nsz
+	}
+	return pos
+}
+
+// joinsWord returns true if mid does not break a word when found between the
+// runes prev and next.
+func joinsWord(prev, mid, next rune) (joins bool) {
+	switch {
+	case unicode.IsLetter(prev) && unicode.IsLetter(next):
+		joins = strings.ContainsRune("'’.:·", mid)
+	case unicode.IsDigit(prev) && unicode.IsDigit(next):
+		joins = strings.ContainsRune(",.", mid)
+	}
+	return joins
+}
+
+func isWordRune(r rune) bool {
+	if r < utf8.RuneSelf {
+		return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9'
+	}
+	return unicode.In(r, unicode.Letter, unicode.Digit, unicode.Mark)
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana)
+}
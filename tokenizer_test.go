@@ -0,0 +1,52 @@
+package diffator_test
+
+import (
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWordTokenizer(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{
+			name: "Empty",
+			s:    "",
+			want: nil,
+		},
+		{
+			name: "Words, punctuation and whitespace",
+			s:    "Look, it's  Batman!!!",
+			want: []string{"Look", ",", " ", "it's", "  ", "Batman", "!", "!", "!"},
+		},
+		{
+			name: "Numbers and identifiers",
+			s:    "total=1,000.50 for user_id",
+			want: []string{"total", "=", "1,000.50", " ", "for", " ", "user_id"},
+		},
+		{
+			name: "Trailing apostrophe is not part of the word",
+			s:    "the users' names",
+			want: []string{"the", " ", "users", "'", " ", "names"},
+		},
+		{
+			name: "Ideographs",
+			s:    "日本語 text",
+			want: []string{"日", "本", "語", " ", "text"},
+		},
+		{
+			name: "Accented letters",
+			s:    "café crème",
+			want: []string{"café", " ", "crème"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffator.WordTokenizer(tt.s))
+		})
+	}
+}
@@ -1,5 +1,9 @@
 package diffator
 
+import (
+	"strings"
+)
+
 var _ fixer = (*tree)(nil)
 
 type tree struct {
@@ -12,9 +16,29 @@ type tree struct {
 func (*tree) Fixer() {}
 
 func (t *tree) String() string {
-	return t.prefix.String() +
-		t.infix.String() +
-		t.suffix.String()
+	var sb strings.Builder
+	t.writeString(&sb)
+	return sb.String()
+}
+
+// writeString writes the tree to sb, so that rendering a deep tree copies each
+// node's text only once.
+func (t *tree) writeString(sb *strings.Builder) {
+	for _, f := range []fixer{t.prefix, t.infix, t.suffix} {
+		switch f := f.(type) {
+		case *tree:
+			f.writeString(sb)
+		default:
+			sb.WriteString(f.String())
+		}
+	}
+}
+
+func (t *tree) segments() (segs []segment) {
+	segs = append(segs, t.prefix.segments()...)
+	segs = append(segs, t.infix.segments()...)
+	segs = append(segs, t.suffix.segments()...)
+	return segs
 }
 
 func newTree(opts *StringOpts) *tree {
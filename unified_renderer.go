@@ -0,0 +1,81 @@
+package diffator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// renderUnified renders segments as a unified diff, with `---` and `+++`
+// headers naming the strings and a `@@ -a,b +c,d @@` header for each hunk of
+// changed lines and their surrounding ContextLines.
+func (opts *StringOpts) renderUnified(segs []segment) (s string) {
+	var n1, n2 int
+	var sb strings.Builder
+
+	lines := toLines(segs)
+	show, changed := opts.contextMask(lines)
+	if !changed {
+		goto end
+	}
+	sb.WriteString(fmt.Sprintf("--- %s\n", opts.FromFile.Value))
+	sb.WriteString(fmt.Sprintf("+++ %s\n", opts.ToFile.Value))
+	for i := 0; i < len(lines); {
+		if !show[i] {
+			n1, n2 = countLine(lines[i], n1, n2)
+			i++
+			continue
+		}
+		j := i
+		len1, len2 := 0, 0
+		for ; j < len(lines) && show[j]; j++ {
+			len1, len2 = countLine(lines[j], len1, len2)
+		}
+		sb.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(n1, len1),
+			hunkRange(n2, len2),
+		))
+		for ; i < j; i++ {
+			line := lines[i]
+			sb.WriteString(line.styled(opts.style))
+			sb.WriteByte('\n')
+			if !line.eol {
+				sb.WriteString(opts.style.Context(noNewlineAtEOF))
+				sb.WriteByte('\n')
+			}
+		}
+		n1 += len1
+		n2 += len2
+	}
+	s = sb.String()
+end:
+	return s
+}
+
+// countLine increments the count of lines seen in the left and/or right string.
+func countLine(line diffLine, n1, n2 int) (int, int) {
+	switch line.kind {
+	case leftSegment:
+		n1++
+	case rightSegment:
+		n2++
+	default:
+		n1++
+		n2++
+	}
+	return n1, n2
+}
+
+// hunkRange formats the range of a hunk that follows the first n lines and is
+// length lines long, as GNU diff does: an empty range starts at the line before
+// it, and a length of one is omitted.
+func hunkRange(n, length int) (s string) {
+	switch length {
+	case 0:
+		s = fmt.Sprintf("%d,0", n)
+	case 1:
+		s = fmt.Sprintf("%d", n+1)
+	default:
+		s = fmt.Sprintf("%d,%d", n+1, length)
+	}
+	return s
+}
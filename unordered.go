@@ -0,0 +1,410 @@
+package diffator
+
+import (
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+)
+
+// minPairSimilarity is the similarity, from 0 to 1, that two elements left
+// unpaired by an unordered comparison must reach to be paired as a changed
+// element rather than reported as deleted and inserted.
+const minPairSimilarity = 0.5
+
+// maxUnorderedPairs limits the number of pairs of elements left unpaired by
+// their hashes that an unordered comparison scores by similarity, and the
+// number of pairs it compares and finds to differ while looking for equal
+// elements. Above it, elements left over are paired in order.
+const maxUnorderedPairs = 1 << 16
+
+// isUnordered returns true if the slice or array rv found at path is to be
+// compared ignoring the order of its elements.
+func (o *ObjectComparator) isUnordered(rv *reflect.Value, path Path) (unordered bool) {
+	opts := o.opts
+	switch {
+	case opts.UnorderedSlices:
+	case o.tag.unordered && len(path) == o.tagDepth:
+	case hasType(opts.unorderedTypes, rv.Type()), hasType(opts.unorderedTypes, rv.Type().Elem()):
+	default:
+		for _, pattern := range opts.unorderedPaths {
+			if pattern.matches(path) {
+				unordered = true
+				break
+			}
+		}
+		goto end
+	}
+	unordered = true
+end:
+	return unordered
+}
+
+func hasType(types map[reflect.Type]struct{}, rt reflect.Type) (has bool) {
+	_, has = types[rt]
+	return has
+}
+
+// elementPair is a candidate pairing of element want of one slice with element
+// got of another, and how similar they are.
+type elementPair struct {
+	want       int
+	got        int
+	similarity float64
+}
+
+// diffUnordered compares the elements of two slices or arrays as multisets,
+// ignoring their order.
+//
+// Elements are first paired with equal elements found via their hashes, which
+// is linear for elements that are equal and hashed alike; see hashElement().
+// Unless there are more than maxUnorderedPairs pairs of elements left over,
+// they are then compared one by one to pair those equal given the comparator's
+// options and then those most similar, whose differences are shown as a
+// changed element. Otherwise, if Ignore rules could make elements equal that
+// hash differently, they are compared to pair those equal until
+// maxUnorderedPairs pairs are found to differ. Any elements still left over
+// are paired in order as changed elements, and the remainder reported as
+// deleted or inserted. Changed and deleted elements are at their index in rv1,
+// and inserted elements at their index in rv2.
+func (o *ObjectComparator) diffUnordered(rv1, rv2 *reflect.Value, path Path) (diffs []*Diff) {
+	var pairs []elementPair
+	var misses int
+
+	n, m := rv1.Len(), rv2.Len()
+	paired1 := make([]int, n)
+	paired2 := make([]bool, m)
+	for i := range paired1 {
+		paired1[i] = -1
+	}
+	pair := func(i, j int) {
+		paired1[i] = j
+		paired2[j] = true
+	}
+	// equal compares elements i and j unless too many pairs have differed
+	equal := func(i, j int) bool {
+		if misses > maxUnorderedPairs {
+			return false
+		}
+		if o.elementDiff(rv1, rv2, i, j, path) == nil {
+			return true
+		}
+		misses++
+		return false
+	}
+
+	buckets := make(map[uint64][]int, m)
+	for j := 0; j < m; j++ {
+		h := o.hashElement(rv2.Index(j), path.withIndex(j))
+		buckets[h] = append(buckets[h], j)
+	}
+	for i := 0; i < n; i++ {
+		for _, j := range buckets[o.hashElement(rv1.Index(i), path.withIndex(i))] {
+			if !paired2[j] && equal(i, j) {
+				pair(i, j)
+				break
+			}
+		}
+	}
+
+	left1, left2 := unpairedIndexes(paired1, paired2)
+	scored := len(left1)*len(left2) <= maxUnorderedPairs
+	switch {
+	case scored:
+		pairs = o.similarElements(rv1, rv2, left1, left2, path)
+	case len(o.opts.Ignore) > 0:
+		pairs = equalElements(left1, left2, equal)
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].similarity > pairs[b].similarity
+	})
+	changed := make([]bool, n)
+	for _, p := range pairs {
+		if paired1[p.want] >= 0 || paired2[p.got] {
+			continue
+		}
+		pair(p.want, p.got)
+		changed[p.want] = p.similarity < 1
+	}
+	if !scored {
+		left1, left2 = unpairedIndexes(paired1, paired2)
+		for k := 0; k < min(len(left1), len(left2)); k++ {
+			pair(left1[k], left2[k])
+			changed[left1[k]] = true
+		}
+	}
+
+	for i, j := range paired1 {
+		if !changed[i] {
+			continue
+		}
+		if d := o.elementDiff(rv1, rv2, i, j, path); d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+	for i, j := range paired1 {
+		if j >= 0 {
+			continue
+		}
+		idx := rv1.Index(i)
+		d := o.missingDiff(MissingExpectedDiff, path.withIndex(i), &idx, nil)
+		if d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+	for j, ok := range paired2 {
+		if ok {
+			continue
+		}
+		idx := rv2.Index(j)
+		d := o.missingDiff(MissingActualDiff, path.withIndex(j), nil, &idx)
+		if d != nil {
+			diffs = append(diffs, d)
+		}
+	}
+	return diffs
+}
+
+// equalElements compares each element indexed by left1 with each indexed by
+// left2 not already found equal, and returns the pairs that are equal, with
+// similarity 1.
+func equalElements(left1, left2 []int, equal func(i, j int) bool) (pairs []elementPair) {
+	found := make([]bool, len(left2))
+	for _, i := range left1 {
+		for k, j := range left2 {
+			if !found[k] && equal(i, j) {
+				pairs = append(pairs, elementPair{want: i, got: j, similarity: 1})
+				found[k] = true
+				break
+			}
+		}
+	}
+	return pairs
+}
+
+// similarElements compares each element of rv1 indexed by left1 with each of
+// rv2 indexed by left2, and returns the pairs that are equal, with similarity
+// 1, and those similar enough to be paired as a changed element.
+func (o *ObjectComparator) similarElements(rv1, rv2 *reflect.Value, left1, left2 []int, path Path) (pairs []elementPair) {
+	equal := make([]bool, len(left2))
+	for _, i := range left1 {
+		for k, j := range left2 {
+			if equal[k] {
+				continue
+			}
+			d := o.elementDiff(rv1, rv2, i, j, path)
+			if d == nil {
+				pairs = append(pairs, elementPair{want: i, got: j, similarity: 1})
+				equal[k] = true
+				break
+			}
+			size := max(valueSize(rv1.Index(i), 0), valueSize(rv2.Index(j), 0))
+			similarity := 1 - float64(len(d.Leaves()))/float64(size)
+			if similarity >= minPairSimilarity {
+				pairs = append(pairs, elementPair{want: i, got: j, similarity: similarity})
+			}
+		}
+	}
+	return pairs
+}
+
+// elementDiff compares element i of rv1 with element j of rv2.
+func (o *ObjectComparator) elementDiff(rv1, rv2 *reflect.Value, i, j int, path Path) *Diff {
+	idx1 := rv1.Index(i)
+	idx2 := rv2.Index(j)
+	d := o.diffValues(&idx1, &idx2, path.withIndex(i))
+	d.setGotIndex(j)
+	return d
+}
+
+func unpairedIndexes(paired1 []int, paired2 []bool) (left1, left2 []int) {
+	for i, j := range paired1 {
+		if j < 0 {
+			left1 = append(left1, i)
+		}
+	}
+	for j, ok := range paired2 {
+		if !ok {
+			left2 = append(left2, j)
+		}
+	}
+	return left1, left2
+}
+
+// valueSize returns the number of scalar values that make up rv, counting an
+// empty or nil container as one.
+func valueSize(rv reflect.Value, depth int) (size int) {
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !rv.IsNil() && depth < maxOrderDepth {
+			size = valueSize(rv.Elem(), depth+1)
+		}
+	case reflect.Struct:
+		for i := 0; i < rv.NumField(); i++ {
+			size += valueSize(rv.Field(i), depth)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			size += valueSize(rv.Index(i), depth)
+		}
+	case reflect.Map:
+		iter := rv.MapRange()
+		for iter.Next() {
+			size += valueSize(iter.Value(), depth)
+		}
+	}
+	return max(size, 1)
+}
+
+// hashElement returns a hash of element rv found at path such that elements the
+// comparator finds equal have equal hashes, as far as it can tell without
+// comparing them. Ignored values, the values of types compared by a
+// CustomComparer or an Equal method, floats compared with a tolerance and the
+// order of nested slices and arrays do not contribute to it. Ignore rules that
+// depend on the values themselves can still hash equal elements differently.
+func (o *ObjectComparator) hashElement(rv reflect.Value, path Path) uint64 {
+	h := fnv.New64a()
+	o.writeHash(h, rv, path, o.exactFloats(o.tag.tolerance), 0)
+	return h.Sum64()
+}
+
+// hashElements returns the hashes of the elements of rv found at path, per
+// hashElement().
+func (o *ObjectComparator) hashElements(rv *reflect.Value, path Path) []uint64 {
+	hashes := make([]uint64, rv.Len())
+	for i := range hashes {
+		hashes[i] = o.hashElement(rv.Index(i), path.withIndex(i))
+	}
+	return hashes
+}
+
+// exactFloats returns true if floats are compared exactly given the float
+// options and tolerance, the tolerance of the struct field holding them.
+func (o *ObjectComparator) exactFloats(tolerance float64) bool {
+	opts := o.opts
+	return tolerance == 0 && opts.FloatAbsEpsilon == 0 && opts.FloatRelEpsilon == 0 && opts.FloatMaxULPs == 0
+}
+
+// hasCustomComparison returns true if values of type rt are compared by a
+// CustomComparer or an Equal method.
+func (o *ObjectComparator) hasCustomComparison(rt reflect.Type) (custom bool) {
+	if rt.Kind() == reflect.Interface {
+		goto end
+	}
+	if _, custom = o.opts.comparers[rt]; custom {
+		goto end
+	}
+	custom = !o.opts.IgnoreEqualMethods && equalMethod(rt) != nil
+end:
+	return custom
+}
+
+// writeHash writes the hash of rv found at path to h, for hashElement(). Float
+// values are written only if floats is true.
+func (o *ObjectComparator) writeHash(h hash.Hash64, rv reflect.Value, path Path, floats bool, depth int) {
+	var buf [8]byte
+	writeUint := func(u uint64) {
+		binary.LittleEndian.PutUint64(buf[:], u)
+		_, _ = h.Write(buf[:])
+	}
+	writeFloat := func(f float64) {
+		switch {
+		case !floats:
+		case f == 0:
+			// Hash -0 and +0 alike
+			writeUint(0)
+		case math.IsNaN(f):
+			// Hash all NaNs alike, for ObjectOpts.NaNEqual
+			writeUint(1)
+		default:
+			writeUint(math.Float64bits(f))
+		}
+	}
+	// subHash returns the hash of rv found at path on its own, to be combined
+	// regardless of order
+	subHash := func(rv reflect.Value, path Path) uint64 {
+		h := fnv.New64a()
+		o.writeHash(h, rv, path, floats, depth)
+		return h.Sum64()
+	}
+
+	if !rv.IsValid() {
+		writeUint(0)
+		return
+	}
+	if o.ignored(path, &rv, nil) {
+		return
+	}
+	writeUint(uint64(rv.Kind()))
+	if o.hasCustomComparison(rv.Type()) {
+		_, _ = io.WriteString(h, rv.Type().String())
+		return
+	}
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			writeUint(1)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(rv.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeFloat(rv.Float())
+	case reflect.Complex64, reflect.Complex128:
+		writeFloat(real(rv.Complex()))
+		writeFloat(imag(rv.Complex()))
+	case reflect.String:
+		_, _ = io.WriteString(h, rv.String())
+	case reflect.Struct:
+		tags := structTags(rv.Type())
+		for i := 0; i < rv.NumField(); i++ {
+			if tags[i].skip {
+				continue
+			}
+			fldPath := path.withField(tags[i].name, i)
+			o.writeHash(h, rv.Field(i), fldPath, o.exactFloats(tags[i].tolerance), depth)
+		}
+	case reflect.Slice, reflect.Array:
+		// Sum the hashes of the elements, as they may be compared unordered or
+		// by key
+		var sum uint64
+		for i := 0; i < rv.Len(); i++ {
+			idx, idxPath := rv.Index(i), path.withIndex(i)
+			if o.ignored(idxPath, &idx, nil) {
+				continue
+			}
+			sum += subHash(idx, idxPath)
+		}
+		writeUint(sum)
+	case reflect.Map:
+		// Sum the hashes of the entries so the order of iteration is moot
+		var sum uint64
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, val := iter.Key(), iter.Value()
+			keyPath := path.withKey(key)
+			if o.ignored(keyPath, &val, nil) {
+				continue
+			}
+			sum += subHash(key, nil)*31 ^ subHash(val, keyPath)
+		}
+		writeUint(sum)
+	case reflect.Pointer, reflect.Interface:
+		if !rv.IsNil() && depth < maxOrderDepth {
+			o.writeHash(h, rv.Elem(), path, floats, depth+1)
+		}
+	case reflect.Chan:
+		writeUint(uint64(rv.Pointer()))
+	case reflect.Func:
+		// Funcs are only compared as to whether they are nil
+		if rv.IsNil() {
+			writeUint(1)
+		}
+	case reflect.UnsafePointer:
+		// Unsafe pointers are never compared
+	}
+}
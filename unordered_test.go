@@ -0,0 +1,249 @@
+package diffator_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/mikeschinkel/go-diffator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type UnorderedRow struct {
+	ID    int
+	Name  string
+	Tags  []string
+	Attrs map[string]int
+}
+
+type UnorderedTeam struct {
+	Name    string
+	Members []string
+	Scores  []int
+}
+
+func TestCompareObjectsUnordered(t *testing.T) {
+	tests := []struct {
+		name     string
+		v1       any
+		v2       any
+		opts     *diffator.ObjectOpts
+		wantDiff string
+	}{
+		{
+			name:     "Reordered is equal",
+			v1:       []int{1, 2, 3, 2},
+			v2:       []int{2, 3, 2, 1},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true},
+			wantDiff: "",
+		},
+		{
+			name:     "Extras on either side",
+			v1:       []int{1, 2, 2, 3},
+			v2:       []int{4, 3, 2, 1},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true},
+			wantDiff: "[]int{[2]<deleted:2>,[0]<inserted:4>,}",
+		},
+		{
+			name:     "Array",
+			v1:       [3]string{"a", "b", "c"},
+			v2:       [3]string{"c", "a", "b"},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true},
+			wantDiff: "",
+		},
+		{
+			name: "Near match shows its fields",
+			v1: []TestStruct{
+				{Int: 1, String: "one"},
+				{Int: 2, String: "two"},
+				{Int: 3, String: "three"},
+			},
+			v2: []TestStruct{
+				{Int: 3, String: "three"},
+				{Int: 2, String: "TWO"},
+				{Int: 1, String: "one"},
+			},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true},
+			wantDiff: `[]diffator_test.TestStruct{[1]diffator_test.TestStruct{String:(two!=TWO),},}`,
+		},
+		{
+			name: "Dissimilar elements are not paired",
+			v1: []TestStruct{
+				{Int: 1, String: "one"},
+			},
+			v2: []TestStruct{
+				{Int: 2, String: "two"},
+			},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true},
+			wantDiff: `[]diffator_test.TestStruct{[0]<deleted:diffator_test.TestStruct{Int:1,String:"one",}>,[0]<inserted:diffator_test.TestStruct{Int:2,String:"two",}>,}`,
+		},
+		{
+			name: "Non-comparable elements",
+			v1: []UnorderedRow{
+				{ID: 1, Name: "a", Tags: []string{"x"}, Attrs: map[string]int{"k": 1, "j": 2}},
+				{ID: 2, Name: "b", Tags: []string{"y", "z"}, Attrs: map[string]int{"k": 2}},
+				{ID: 3, Name: "c", Tags: nil, Attrs: nil},
+			},
+			v2: []UnorderedRow{
+				{ID: 3, Name: "c", Tags: nil, Attrs: nil},
+				{ID: 2, Name: "b", Tags: []string{"y", "z"}, Attrs: map[string]int{"k": 3}},
+				{ID: 1, Name: "a", Tags: []string{"x"}, Attrs: map[string]int{"j": 2, "k": 1}},
+			},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true},
+			wantDiff: `[]diffator_test.UnorderedRow{[1]diffator_test.UnorderedRow{Attrs:map[string]int{k:(2!=3),},},}`,
+		},
+		{
+			name:     "Equal within tolerance",
+			v1:       []float64{1.0, 2.0},
+			v2:       []float64{2.05, 1.05},
+			opts:     &diffator.ObjectOpts{UnorderedSlices: true, FloatAbsEpsilon: 0.1},
+			wantDiff: "",
+		},
+		{
+			name:     "By path",
+			v1:       UnorderedTeam{Name: "t", Members: []string{"a", "b"}, Scores: []int{1, 2}},
+			v2:       UnorderedTeam{Name: "t", Members: []string{"b", "a"}, Scores: []int{2, 1}},
+			opts:     &diffator.ObjectOpts{UnorderedPaths: []string{"Members"}},
+			wantDiff: "diffator_test.UnorderedTeam{Scores:[]int{[0]<deleted:1>,[1]<inserted:1>,},}",
+		},
+		{
+			name:     "By element type",
+			v1:       UnorderedTeam{Name: "t", Members: []string{"a", "b"}, Scores: []int{1, 2}},
+			v2:       UnorderedTeam{Name: "t", Members: []string{"b", "a"}, Scores: []int{2, 1}},
+			opts:     &diffator.ObjectOpts{UnorderedTypes: []any{""}},
+			wantDiff: "diffator_test.UnorderedTeam{Scores:[]int{[0]<deleted:1>,[1]<inserted:1>,},}",
+		},
+		{
+			name:     "By slice type",
+			v1:       UnorderedTeam{Name: "t", Members: []string{"a", "b"}, Scores: []int{1, 2}},
+			v2:       UnorderedTeam{Name: "t", Members: []string{"b", "a"}, Scores: []int{2, 1}},
+			opts:     &diffator.ObjectOpts{UnorderedTypes: []any{reflect.TypeOf([]int{})}},
+			wantDiff: `diffator_test.UnorderedTeam{Members:[]string{[0]<deleted:"a">,[1]<inserted:"a">,},}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantDiff, diffator.CompareObjects(tt.v1, tt.v2, tt.opts))
+		})
+	}
+}
+
+func TestCompareObjectsUnorderedLarge(t *testing.T) {
+	const n = 2000
+	v1 := make([]UnorderedRow, n)
+	v2 := make([]UnorderedRow, n)
+	for i := range v1 {
+		v1[i] = UnorderedRow{ID: i, Tags: []string{"t"}, Attrs: map[string]int{"i": i}}
+		v2[n-1-i] = UnorderedRow{ID: i, Tags: []string{"t"}, Attrs: map[string]int{"i": i}}
+	}
+	v2[0].Name = "changed"
+	got := diffator.CompareObjects(v1, v2, &diffator.ObjectOpts{UnorderedSlices: true})
+	assert.Equal(t, `[]diffator_test.UnorderedRow{[1999]diffator_test.UnorderedRow{Name:(!=changed),},}`, got)
+}
+
+func TestCompareObjectsUnorderedLargeAllChanged(t *testing.T) {
+	// Too many rows differ to compare every pair, so they are paired in order
+	const n = 2000
+	v1 := make([]UnorderedRow, n)
+	v2 := make([]UnorderedRow, n+1)
+	for i := range v1 {
+		v1[i] = UnorderedRow{ID: i}
+		v2[i] = UnorderedRow{ID: i, Name: "changed"}
+	}
+	v2[n] = UnorderedRow{ID: n}
+	got := diffator.CompareObjectsResult(v1, v2, &diffator.ObjectOpts{UnorderedSlices: true})
+	require.NotNil(t, got)
+	require.Len(t, got.Children, n+1)
+	for i, d := range got.Children[:n] {
+		assert.Equal(t, fmt.Sprintf("[%d].Name", i), d.Children[0].Path.String())
+	}
+	assert.Equal(t, diffator.MissingActualDiff, got.Children[n].Kind)
+}
+
+type UnorderedEvent struct {
+	ID    int
+	At    int64
+	Score float64
+}
+
+func TestCompareObjectsUnorderedAboveThreshold(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(e *UnorderedEvent)
+		opts   *diffator.ObjectOpts
+	}{
+		{
+			name:   "Ignored field",
+			change: func(e *UnorderedEvent) { e.At = 1 },
+			opts: &diffator.ObjectOpts{
+				Ignore: []diffator.IgnoreRule{diffator.IgnoreField("At")},
+			},
+		},
+		{
+			name:   "Float tolerance",
+			change: func(e *UnorderedEvent) { e.Score += 0.1 },
+			opts:   &diffator.ObjectOpts{FloatAbsEpsilon: 0.5},
+		},
+		{
+			name:   "Ignore rule depending on the value",
+			change: func(e *UnorderedEvent) { e.At = 1 },
+			opts: &diffator.ObjectOpts{
+				Ignore: []diffator.IgnoreRule{
+					func(path diffator.Path, rv reflect.Value) bool {
+						return path.Last().Name == "At" && rv.Int() == 0
+					},
+				},
+			},
+		},
+	}
+	// 300 rows leave more pairs of rows unpaired by their hashes than are
+	// scored by similarity, if the hashes pair none of them
+	for _, n := range []int{200, 300} {
+		for _, tt := range tests {
+			t.Run(fmt.Sprintf("%s/%d", tt.name, n), func(t *testing.T) {
+				v1 := make([]UnorderedEvent, n)
+				v2 := make([]UnorderedEvent, n)
+				for i := range v1 {
+					v1[i] = UnorderedEvent{ID: i, Score: float64(i)}
+					v2[n-1-i] = v1[i]
+					tt.change(&v2[n-1-i])
+				}
+				tt.opts.UnorderedSlices = true
+				assert.Equal(t, "", diffator.CompareObjects(v1, v2, tt.opts))
+			})
+		}
+	}
+}
+
+// BenchmarkCompareObjectsUnordered benchmarks unordered slices of structs too
+// large for all the pairs of their differing elements to be compared.
+func BenchmarkCompareObjectsUnordered(b *testing.B) {
+	ignore := []diffator.IgnoreRule{diffator.IgnorePath("[*].Tags")}
+	benchmarks := []struct {
+		name   string
+		n      int
+		change func(i int, r *BenchmarkRow)
+		ignore []diffator.IgnoreRule
+	}{
+		{name: "3000 shuffled", n: 3000, change: func(int, *BenchmarkRow) {}},
+		{name: "1000 disjoint", n: 1000, change: func(i int, r *BenchmarkRow) { r.ID = -i - 1; r.Name = "other" }},
+		{name: "3000 disjoint", n: 3000, change: func(i int, r *BenchmarkRow) { r.ID = -i - 1; r.Name = "other" }},
+		{name: "2000 all changed", n: 2000, change: func(_ int, r *BenchmarkRow) { r.Name += "!" }},
+		{name: "2000 all changed with ignore", n: 2000, change: func(_ int, r *BenchmarkRow) { r.Name += "!" }, ignore: ignore},
+	}
+	for _, bm := range benchmarks {
+		rows1, rows2 := benchmarkRows(bm.n, bm.change)
+		for i := range rows2 {
+			j := (i * 7919) % len(rows2)
+			rows2[i], rows2[j] = rows2[j], rows2[i]
+		}
+		opts := &diffator.ObjectOpts{UnorderedSlices: true, Ignore: bm.ignore}
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				diffator.CompareObjectsResult(rows1, rows2, opts)
+			}
+		})
+	}
+}
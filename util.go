@@ -10,13 +10,15 @@ func panicf(msg string, args ...any) {
 	panic(fmt.Sprintf(msg, args...))
 }
 
+// SortReflectValues returns a sorted copy of rvs, ordered as per
+// CompareReflectValues().
 func SortReflectValues(rvs []reflect.Value) []reflect.Value {
 	keys := make([]reflect.Value, len(rvs))
 	for i, k := range rvs {
 		keys[i] = k
 	}
 	sort.SliceStable(keys, func(i, j int) bool {
-		return keys[i].String() < keys[j].String()
+		return CompareReflectValues(keys[i], keys[j]) < 0
 	})
 	return keys
 }
@@ -51,6 +53,8 @@ func ReflectorsToNameString[S ~[]E, E any](in S) (names string) {
 	return names
 }
 
+// SortedMapKeys returns the keys of a map, passed as is or as a reflect.Value,
+// ordered as per CompareReflectValues().
 func SortedMapKeys(a any) (keys []reflect.Value) {
 	var rv reflect.Value
 	switch t := a.(type) {
@@ -70,7 +74,7 @@ func SortedMapKeys(a any) (keys []reflect.Value) {
 		keys[i] = k
 	}
 	sort.SliceStable(keys, func(i, j int) bool {
-		return keys[i].String() < keys[j].String()
+		return CompareReflectValues(keys[i], keys[j]) < 0
 	})
 	return keys
 }